@@ -0,0 +1,102 @@
+package barrier
+
+import (
+	"context"
+	"sync"
+)
+
+// CyclicBarrier blocks parties until all of them arrived, then releases them together and resets for the next round
+type CyclicBarrier struct {
+	lock       sync.Mutex
+	parties    int
+	generation *generation
+}
+
+// generation is a single round of a CyclicBarrier
+type generation struct {
+	arrived []*arrival
+	values  []interface{}
+	done    chan struct{}
+}
+
+// arrival is a party waiting in a generation
+type arrival struct {
+	value interface{}
+}
+
+func newGeneration(parties int) *generation {
+	return &generation{
+		arrived: make([]*arrival, 0, parties),
+		done:    make(chan struct{}),
+	}
+}
+
+// release sets the generation's values and releases its parties, called under lock
+func (g *generation) release() {
+	g.values = make([]interface{}, len(g.arrived))
+	for i, a := range g.arrived {
+		g.values[i] = a.value
+	}
+	close(g.done)
+}
+
+// leave removes an arrival from the generation, called under lock
+func (g *generation) leave(a *arrival) {
+	for i := range g.arrived {
+		if g.arrived[i] == a {
+			g.arrived = append(g.arrived[:i], g.arrived[i+1:]...)
+			return
+		}
+	}
+}
+
+// NewCyclicBarrier returns a new CyclicBarrier for the given number of parties
+func NewCyclicBarrier(parties int) *CyclicBarrier {
+	if parties < 1 {
+		parties = 1
+	}
+	return &CyclicBarrier{
+		lock:       sync.Mutex{},
+		parties:    parties,
+		generation: newGeneration(parties),
+	}
+}
+
+// Await blocks until all parties arrived and returns (a copy of) the values they arrived with.
+// If the context is done first the party leaves the round (its value is discarded) and the context's error is returned
+func (b *CyclicBarrier) Await(ctx context.Context, value interface{}) ([]interface{}, error) {
+	b.lock.Lock()
+	g := b.generation
+	a := &arrival{value: value}
+	g.arrived = append(g.arrived, a)
+	if len(g.arrived) == b.parties {
+		b.generation = newGeneration(b.parties)
+		g.release()
+		b.lock.Unlock()
+		return append([]interface{}{}, g.values...), nil
+	}
+	b.lock.Unlock()
+
+	select {
+	case <-g.done:
+		return append([]interface{}{}, g.values...), nil
+	case <-ctx.Done():
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		select {
+		case <-g.done: // released while waiting for the lock
+			return append([]interface{}{}, g.values...), nil
+		default:
+		}
+		g.leave(a)
+		return nil, ctx.Err()
+	}
+}
+
+// Waiting returns the number of parties waiting in the current round
+func (b *CyclicBarrier) Waiting() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.generation.arrived)
+}
@@ -0,0 +1,47 @@
+package barrier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCyclicBarrier(t *testing.T) {
+	b := NewCyclicBarrier(3)
+
+	for round := 0; round < 2; round++ {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				values, err := b.Await(context.Background(), i)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []interface{}{0, 1, 2}, values)
+				values[0] = nil // every party gets its own copy
+			}(i)
+		}
+		wg.Wait()
+		require.EqualValues(t, 0, b.Waiting())
+	}
+}
+
+func TestCyclicBarrier_Context(t *testing.T) {
+	b := NewCyclicBarrier(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	values, err := b.Await(ctx, "left")
+	require.EqualError(t, err, context.DeadlineExceeded.Error())
+	require.Nil(t, values)
+	require.EqualValues(t, 0, b.Waiting())
+
+	// the party that left is not part of the next release
+	go b.Await(context.Background(), 1)
+	values, err = b.Await(context.Background(), 2)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []interface{}{1, 2}, values)
+}
@@ -0,0 +1,79 @@
+package barrier
+
+import (
+	"context"
+	"sync"
+)
+
+// CountDownLatch blocks waiters until it was counted down count times
+type CountDownLatch struct {
+	lock   sync.Mutex
+	count  int
+	values []interface{}
+	done   chan struct{}
+}
+
+// NewCountDownLatch returns a new CountDownLatch, a latch with count <= 0 is already open
+func NewCountDownLatch(count int) *CountDownLatch {
+	l := &CountDownLatch{
+		lock:   sync.Mutex{},
+		count:  count,
+		values: make([]interface{}, 0),
+		done:   make(chan struct{}),
+	}
+	if count <= 0 {
+		l.count = 0
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown decrements the count, once it reaches 0 all waiters are released
+func (l *CountDownLatch) CountDown() {
+	l.countDown(nil, false)
+}
+
+// CountDownWith is like CountDown, value is recorded and returned by Wait
+func (l *CountDownLatch) CountDownWith(value interface{}) {
+	l.countDown(value, true)
+}
+
+// Count returns the remaining count
+func (l *CountDownLatch) Count() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.count
+}
+
+// Done returns a chan which is closed once the count reaches 0
+func (l *CountDownLatch) Done() <-chan struct{} {
+	return l.done
+}
+
+// Wait blocks until the count reaches 0 and returns the values passed to CountDownWith, or the context's error if done first
+func (l *CountDownLatch) Wait(ctx context.Context) ([]interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		return append([]interface{}{}, l.values...), nil
+	}
+}
+
+func (l *CountDownLatch) countDown(value interface{}, record bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.count == 0 {
+		return
+	}
+	if record {
+		l.values = append(l.values, value)
+	}
+	l.count--
+	if l.count == 0 {
+		close(l.done)
+	}
+}
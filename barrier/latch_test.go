@@ -0,0 +1,43 @@
+package barrier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountDownLatch(t *testing.T) {
+	l := NewCountDownLatch(3)
+	go func() {
+		l.CountDownWith(1)
+		l.CountDown()
+		l.CountDownWith(3)
+		l.CountDownWith(4) // ignored once open
+	}()
+
+	values, err := l.Wait(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, []interface{}{1, 3}, values)
+	require.EqualValues(t, 0, l.Count())
+	<-l.Done()
+}
+
+func TestCountDownLatch_Context(t *testing.T) {
+	l := NewCountDownLatch(2)
+	l.CountDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	values, err := l.Wait(ctx)
+	require.EqualError(t, err, context.DeadlineExceeded.Error())
+	require.Nil(t, values)
+	require.EqualValues(t, 1, l.Count())
+}
+
+func TestCountDownLatch_Open(t *testing.T) {
+	values, err := NewCountDownLatch(0).Wait(context.Background())
+	require.NoError(t, err)
+	require.Len(t, values, 0)
+}
@@ -0,0 +1,103 @@
+package barrier
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bloxapp/go-threading/channel"
+)
+
+// Predicate returns true once the collected values form a quorum
+type Predicate func(values []interface{}) bool
+
+// Threshold returns a Predicate which is met once n values were collected
+func Threshold(n int) Predicate {
+	return func(values []interface{}) bool {
+		return len(values) >= n
+	}
+}
+
+// QuorumWaiter collects the values fired on a Channel until a Predicate is met
+type QuorumWaiter struct {
+	src       *channel.Waiter
+	predicate Predicate
+	lock      sync.Mutex
+	values    []interface{}
+	reached   bool
+	done      chan struct{}
+}
+
+// NewQuorumWaiter registers on c and collects fired values until predicate is met, the Channel is closed or Close is called
+func NewQuorumWaiter(c *channel.Channel, predicate Predicate) *QuorumWaiter {
+	q := &QuorumWaiter{
+		src:       c.Register(channel.WithMailbox(0)),
+		predicate: predicate,
+		lock:      sync.Mutex{},
+		values:    make([]interface{}, 0),
+		done:      make(chan struct{}),
+	}
+	go q.collect()
+	return q
+}
+
+// NewThresholdWaiter is like NewQuorumWaiter with a Threshold predicate
+func NewThresholdWaiter(c *channel.Channel, threshold int) *QuorumWaiter {
+	return NewQuorumWaiter(c, Threshold(threshold))
+}
+
+// Wait blocks until the quorum is reached and returns the values which formed it.
+// Returns channel.ErrClosed if the waiter stopped collecting before a quorum, or the context's error if done first
+func (q *QuorumWaiter) Wait(ctx context.Context) ([]interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.done:
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if !q.reached {
+		return nil, channel.ErrClosed
+	}
+	return append([]interface{}{}, q.values...), nil
+}
+
+// Done returns a chan which is closed once the waiter stopped collecting
+func (q *QuorumWaiter) Done() <-chan struct{} {
+	return q.done
+}
+
+// Values returns the values collected so far
+func (q *QuorumWaiter) Values() []interface{} {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([]interface{}{}, q.values...)
+}
+
+// Close stops collecting and deregisters from the Channel
+func (q *QuorumWaiter) Close() {
+	q.src.Close()
+	<-q.done
+}
+
+// collect receives values until the predicate is met or the source is closed
+func (q *QuorumWaiter) collect() {
+	defer close(q.done)
+	defer q.src.Close()
+
+	for {
+		obj, ok := q.src.Receive()
+		if !ok {
+			return
+		}
+
+		q.lock.Lock()
+		q.values = append(q.values, obj)
+		if q.predicate(q.values) {
+			q.reached = true
+			q.lock.Unlock()
+			return
+		}
+		q.lock.Unlock()
+	}
+}
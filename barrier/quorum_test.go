@@ -0,0 +1,78 @@
+package barrier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/bloxapp/go-threading/channel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuorumWaiter_Threshold(t *testing.T) {
+	c := channel.New()
+	q := NewThresholdWaiter(c, 3)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.FireToAll(i))
+	}
+
+	values, err := q.Wait(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, []interface{}{0, 1, 2}, values)
+	goleak.VerifyNone(t)
+}
+
+func TestQuorumWaiter_Predicate(t *testing.T) {
+	c := channel.New()
+	// a quorum of distinct signers
+	q := NewQuorumWaiter(c, func(values []interface{}) bool {
+		signers := make(map[interface{}]bool)
+		for _, v := range values {
+			signers[v] = true
+		}
+		return len(signers) >= 2
+	})
+
+	c.FireToAll("a")
+	c.FireToAll("a")
+	c.FireToAll("b")
+
+	values, err := q.Wait(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, []interface{}{"a", "a", "b"}, values)
+	goleak.VerifyNone(t)
+}
+
+func TestQuorumWaiter_NotReached(t *testing.T) {
+	t.Run("context done", func(t *testing.T) {
+		c := channel.New()
+		q := NewThresholdWaiter(c, 2)
+		c.FireToAll(1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		_, err := q.Wait(ctx)
+		require.EqualError(t, err, context.DeadlineExceeded.Error())
+		require.Eventually(t, func() bool {
+			return len(q.Values()) == 1
+		}, time.Second, time.Millisecond)
+
+		q.Close()
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("channel closed", func(t *testing.T) {
+		c := channel.New()
+		q := NewThresholdWaiter(c, 2)
+		c.FireToAll(1)
+		c.CancelAll()
+
+		_, err := q.Wait(context.Background())
+		require.EqualError(t, err, channel.ErrClosed.Error())
+		require.EqualValues(t, []interface{}{1}, q.Values())
+		goleak.VerifyNone(t)
+	})
+}
@@ -0,0 +1,111 @@
+package bridge
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/bloxapp/go-threading/channel"
+	"github.com/stretchr/testify/require"
+)
+
+// serve serves c on a new socket in a temp dir
+func serve(t *testing.T, c *channel.Channel, codec Codec) (*Server, string) {
+	path := filepath.Join(t.TempDir(), "bridge.sock")
+	s, err := Serve(c, path, codec)
+	require.NoError(t, err)
+	return s, path
+}
+
+// connected waits for n connections to be registered on c
+func connected(t *testing.T, c *channel.Channel, n int) {
+	require.Eventually(t, func() bool {
+		return c.Len() == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestBridge(t *testing.T) {
+	c := channel.New()
+	s, path := serve(t, c, Gob)
+	w1 := Subscribe(context.Background(), path, Gob)
+	w2 := Subscribe(context.Background(), path, Gob)
+	connected(t, c, 2)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.FireToAll(duty{Slot: uint64(i)}))
+	}
+	for _, w := range []*channel.Waiter{w1, w2} {
+		for i := 0; i < 3; i++ {
+			obj, err := w.WaitTimeout(time.Second)
+			require.NoError(t, err)
+			require.EqualValues(t, duty{Slot: uint64(i)}, obj)
+		}
+	}
+
+	// closing a client deregisters its connection
+	w1.Close()
+	connected(t, c, 1)
+
+	// cancelling the channel closes clients
+	c.CancelAll()
+	_, ok := w2.Receive()
+	require.False(t, ok)
+
+	s.Close()
+	goleak.VerifyNone(t)
+}
+
+func TestBridge_Reconnect(t *testing.T) {
+	c := channel.New()
+	s, path := serve(t, c, JSON)
+	w := Subscribe(context.Background(), path, JSON)
+	connected(t, c, 1)
+
+	c.FireToAll("before")
+	obj, err := w.WaitTimeout(time.Second)
+	require.NoError(t, err)
+	require.EqualValues(t, "before", obj)
+
+	// the client keeps retrying while the server is down
+	s.Close()
+	time.Sleep(ReconnectInterval * 2)
+	select {
+	case <-w.Done():
+		t.Fatal("waiter closed while reconnecting")
+	default:
+	}
+
+	s, err = Serve(c, path, JSON)
+	require.NoError(t, err)
+	connected(t, c, 1)
+
+	c.FireToAll("after")
+	obj, err = w.WaitTimeout(time.Second)
+	require.NoError(t, err)
+	require.EqualValues(t, "after", obj)
+
+	w.Close()
+	connected(t, c, 0)
+	s.Close()
+	goleak.VerifyNone(t)
+}
+
+func TestBridge_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// nothing listens yet
+	w := Subscribe(ctx, filepath.Join(t.TempDir(), "bridge.sock"), JSON)
+	time.Sleep(ReconnectInterval)
+	cancel()
+
+	_, ok := w.Receive()
+	require.False(t, ok)
+	goleak.VerifyNone(t)
+}
+
+func TestServe_Error(t *testing.T) {
+	_, err := Serve(channel.New(), filepath.Join(t.TempDir(), "missing", "bridge.sock"), JSON)
+	require.Error(t, err)
+}
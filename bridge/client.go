@@ -0,0 +1,79 @@
+package bridge
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/bloxapp/go-threading/channel"
+)
+
+const (
+	ReconnectInterval = time.Millisecond * 50
+)
+
+// Subscribe connects to the server listening on the unix socket at path and returns a waiter receiving the objects fired through the served channel.
+// The connection is retried every ReconnectInterval until the context is done or the waiter is closed, objects fired while disconnected are lost.
+// The waiter is closed once the served channel is cancelled or the context is done
+func Subscribe(ctx context.Context, path string, codec Codec, opts ...channel.WaiterOption) *channel.Waiter {
+	w := channel.NewWaiter(opts...)
+	go subscribe(ctx, path, codec, w)
+	return w
+}
+
+// subscribe receives objects into w, reconnecting until done
+func subscribe(ctx context.Context, path string, codec Codec, w *channel.Waiter) {
+	defer w.Close()
+
+	dialer := net.Dialer{}
+	for {
+		conn, err := dialer.DialContext(ctx, "unix", path)
+		if err == nil && receive(ctx, conn, codec, w) {
+			return
+		}
+
+		t := time.NewTimer(ReconnectInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-w.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// receive fires the objects read from the connection to w until disconnected, returns true if no reconnect is needed
+func receive(ctx context.Context, conn net.Conn, codec Codec, w *channel.Waiter) bool {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.Done():
+		case <-stop:
+		}
+		conn.Close()
+	}()
+
+	dec := codec.NewDecoder(conn)
+	for {
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			select {
+			case <-ctx.Done():
+				return true
+			case <-w.Done():
+				return true
+			default:
+				return false
+			}
+		}
+		if msg.Closed {
+			return true
+		}
+		w.Fire(msg.Value)
+	}
+}
@@ -0,0 +1,57 @@
+package bridge
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+)
+
+// Codec serializes the objects passed over a bridge connection
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes objects to a stream
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads objects from a stream
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+var (
+	// JSON encodes objects as JSON, they're received as generic JSON values (float64, string, bool, []interface{}, map[string]interface{})
+	JSON Codec = jsonCodec{}
+	// Gob encodes objects with encoding/gob, they're received with their original types which (other than basic types) must be registered with gob.Register by both processes
+	Gob Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+func (gobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+// message is passed from the server to its clients
+type message struct {
+	Value interface{}
+	// Closed is set once the served channel was cancelled, no more messages follow
+	Closed bool
+}
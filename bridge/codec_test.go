@@ -0,0 +1,51 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type duty struct {
+	Slot uint64
+	Role string
+}
+
+func init() {
+	gob.Register(duty{})
+}
+
+func TestCodecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		codec    Codec
+		value    interface{}
+		expected interface{}
+	}{
+		{"json number", JSON, 1, float64(1)},
+		{"json struct", JSON, duty{Slot: 1, Role: "attester"}, map[string]interface{}{"Slot": float64(1), "Role": "attester"}},
+		{"gob number", Gob, 1, 1},
+		{"gob struct", Gob, duty{Slot: 1, Role: "attester"}, duty{Slot: 1, Role: "attester"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			enc := test.codec.NewEncoder(buf)
+			require.NoError(t, enc.Encode(message{Value: test.value}))
+			require.NoError(t, enc.Encode(message{Closed: true}))
+
+			dec := test.codec.NewDecoder(buf)
+			var msg message
+			require.NoError(t, dec.Decode(&msg))
+			require.EqualValues(t, test.expected, msg.Value)
+			require.False(t, msg.Closed)
+
+			msg = message{}
+			require.NoError(t, dec.Decode(&msg))
+			require.True(t, msg.Closed)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package bridge
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/bloxapp/go-threading/channel"
+	"github.com/pkg/errors"
+)
+
+// Server exposes a channel over a unix domain socket, every connection is registered as a waiter and receives the objects fired through the channel
+type Server struct {
+	c        *channel.Channel
+	codec    Codec
+	opts     []channel.WaiterOption
+	listener net.Listener
+	lock     sync.Mutex
+	conns    map[net.Conn]*channel.Waiter
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// Serve listens on the unix socket at path and serves c until closed.
+// opts configure the waiter registered for every connection, by default it has an unbounded mailbox so slow connections never block firing
+func Serve(c *channel.Channel, path string, codec Codec, opts ...channel.WaiterOption) (*Server, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not listen")
+	}
+
+	s := &Server{
+		c:        c,
+		codec:    codec,
+		opts:     append([]channel.WaiterOption{channel.WithMailbox(0)}, opts...),
+		listener: l,
+		lock:     sync.Mutex{},
+		conns:    make(map[net.Conn]*channel.Waiter),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Close stops listening and closes all connections, clients will try to reconnect
+func (s *Server) Close() {
+	s.lock.Lock()
+	s.closed = true
+	s.listener.Close()
+	for conn, w := range s.conns {
+		conn.Close()
+		w.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+}
+
+// accept registers a waiter for every new connection until the listener is closed
+func (s *Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			conn.Close()
+			return
+		}
+		w := s.c.Register(s.opts...)
+		s.conns[conn] = w
+		s.wg.Add(2)
+		s.lock.Unlock()
+
+		go s.write(conn, w)
+		go s.read(conn, w)
+	}
+}
+
+// write passes the objects received by the waiter to the connection until either is closed
+func (s *Server) write(conn net.Conn, w *channel.Waiter) {
+	defer s.wg.Done()
+	defer s.drop(conn, w)
+
+	enc := s.codec.NewEncoder(conn)
+	for {
+		obj, ok := w.Receive()
+		if !ok {
+			if s.c.IsClosed() {
+				_ = enc.Encode(message{Closed: true})
+			}
+			return
+		}
+		if err := enc.Encode(message{Value: obj}); err != nil {
+			return
+		}
+	}
+}
+
+// read waits for the client to disconnect, clients don't send anything
+func (s *Server) read(conn net.Conn, w *channel.Waiter) {
+	defer s.wg.Done()
+	defer s.drop(conn, w)
+
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+// drop closes the connection and its waiter
+func (s *Server) drop(conn net.Conn, w *channel.Waiter) {
+	s.lock.Lock()
+	delete(s.conns, conn)
+	s.lock.Unlock()
+
+	conn.Close()
+	w.Close()
+}
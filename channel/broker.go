@@ -0,0 +1,139 @@
+package channel
+
+import (
+	"strings"
+	"sync"
+)
+
+// Wildcard at the end of a topic pattern subscribes to every topic with the pattern's prefix, "*" subscribes to all topics
+const Wildcard = "*"
+
+// Broker manages named topics, each topic is a Channel.
+// Topics are created by the first subscription and removed once their last subscriber unsubscribes or is closed.
+type Broker struct {
+	lock     sync.RWMutex
+	topics   map[string]*Channel
+	prefixes map[string]*Channel // prefixes holds wildcard subscriptions by the pattern's prefix
+	closed   bool
+}
+
+// NewBroker returns a new Broker
+func NewBroker() *Broker {
+	return &Broker{
+		lock:     sync.RWMutex{},
+		topics:   make(map[string]*Channel),
+		prefixes: make(map[string]*Channel),
+	}
+}
+
+// Subscribe returns a waiter for the topic (or wildcard pattern), WithFilter can be used to receive only some of the messages.
+// Subscribing to a closed broker returns a closed waiter
+func (b *Broker) Subscribe(topic string, opts ...WaiterOption) *Waiter {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		w := NewWaiter(opts...)
+		w.close()
+		return w
+	}
+
+	channels, key := b.channelsFor(topic)
+	c, ok := channels[key]
+	if !ok {
+		c = New()
+		c.onEmpty = func() {
+			b.removeEmpty(channels, key, c)
+		}
+		channels[key] = c
+	}
+	return c.Register(opts...)
+}
+
+// Unsubscribe removes and closes the waiter of the topic (or wildcard pattern) it was subscribed to, the topic is removed if it has no more subscribers
+func (b *Broker) Unsubscribe(topic string, waiter *Waiter) {
+	b.lock.RLock()
+	channels, key := b.channelsFor(topic)
+	c, ok := channels[key]
+	b.lock.RUnlock()
+
+	if ok {
+		// the topic is removed by the channel's onEmpty, which locks the broker
+		c.DeRegister(waiter)
+	}
+}
+
+// removeEmpty deletes the channel if it's still mapped to key and has no waiters
+func (b *Broker) removeEmpty(channels map[string]*Channel, key string, c *Channel) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if channels[key] == c && c.Len() == 0 {
+		delete(channels, key)
+	}
+}
+
+// Publish fires obj to the topic's subscribers and to all matching wildcard subscribers, returns ErrChannelClosed if the broker is closed
+func (b *Broker) Publish(topic string, obj interface{}) error {
+	b.lock.RLock()
+	if b.closed {
+		b.lock.RUnlock()
+		return ErrChannelClosed
+	}
+	subs := make([]*Channel, 0, 1)
+	if c, ok := b.topics[topic]; ok {
+		subs = append(subs, c)
+	}
+	for prefix, c := range b.prefixes {
+		if strings.HasPrefix(topic, prefix) {
+			subs = append(subs, c)
+		}
+	}
+	b.lock.RUnlock()
+
+	// fire outside of the lock so slow subscribers won't block subscribing to other topics
+	for _, c := range subs {
+		c.FireToAll(obj)
+	}
+	return nil
+}
+
+// Topics returns the topics (and wildcard patterns) which have subscribers
+func (b *Broker) Topics() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	ret := make([]string, 0, len(b.topics)+len(b.prefixes))
+	for topic := range b.topics {
+		ret = append(ret, topic)
+	}
+	for prefix := range b.prefixes {
+		ret = append(ret, prefix+Wildcard)
+	}
+	return ret
+}
+
+// Close cancels all topics, closing their subscribers, and rejects any further publishing
+func (b *Broker) Close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.closed = true
+	for _, c := range b.topics {
+		c.CancelAll()
+	}
+	for _, c := range b.prefixes {
+		c.CancelAll()
+	}
+	b.topics = make(map[string]*Channel)
+	b.prefixes = make(map[string]*Channel)
+}
+
+// channelsFor returns the map holding the topic's channel and its key in that map
+// not thread safe, should be called while holding the lock
+func (b *Broker) channelsFor(topic string) (map[string]*Channel, string) {
+	if strings.HasSuffix(topic, Wildcard) {
+		return b.prefixes, strings.TrimSuffix(topic, Wildcard)
+	}
+	return b.topics, topic
+}
@@ -0,0 +1,122 @@
+package channel
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	b := NewBroker()
+	w1 := b.Subscribe("duties.attest")
+	w2 := b.Subscribe("duties.propose")
+
+	require.NoError(t, b.Publish("duties.attest", 1))
+	require.NoError(t, b.Publish("duties.propose", 2))
+	require.NoError(t, b.Publish("no.subscribers", 3))
+
+	require.EqualValues(t, 1, receive(t, w1))
+	require.EqualValues(t, 2, receive(t, w2))
+	require.EqualValues(t, ContextDoneErr, w1.WaitWithTimeout(time.Millisecond*10))
+	require.ElementsMatch(t, []string{"duties.attest", "duties.propose"}, b.Topics())
+}
+
+func TestBroker_Wildcard(t *testing.T) {
+	b := NewBroker()
+	duties := b.Subscribe("duties.*")
+	all := b.Subscribe(Wildcard)
+
+	b.Publish("duties.attest", 1)
+	b.Publish("msgs.consensus", 2)
+
+	require.EqualValues(t, 1, receive(t, duties))
+	require.EqualValues(t, ContextDoneErr, duties.WaitWithTimeout(time.Millisecond*10))
+	require.EqualValues(t, 1, receive(t, all))
+	require.EqualValues(t, 2, receive(t, all))
+	require.ElementsMatch(t, []string{"duties.*", "*"}, b.Topics())
+}
+
+func TestBroker_Filter(t *testing.T) {
+	b := NewBroker()
+	even := b.Subscribe("numbers", WithFilter(func(obj interface{}) bool {
+		return obj.(int)%2 == 0
+	}))
+	all := b.Subscribe("numbers", WithBufferSize(10))
+
+	for i := 0; i < 4; i++ {
+		b.Publish("numbers", i)
+	}
+
+	require.EqualValues(t, 0, receive(t, even))
+	require.EqualValues(t, 2, receive(t, even))
+	require.EqualValues(t, ContextDoneErr, even.WaitWithTimeout(time.Millisecond*10))
+	require.EqualValues(t, 0, even.Dropped())
+	for i := 0; i < 4; i++ {
+		require.EqualValues(t, i, receive(t, all))
+	}
+}
+
+func TestBroker_Cleanup(t *testing.T) {
+	b := NewBroker()
+	w1 := b.Subscribe("topic")
+	w2 := b.Subscribe("topic")
+	w3 := b.Subscribe("prefix.*")
+
+	b.Unsubscribe("topic", w1)
+	require.Len(t, b.topics, 1)
+	b.Unsubscribe("topic", w2)
+	require.Len(t, b.topics, 0)
+	b.Unsubscribe("prefix.*", w3)
+	require.Len(t, b.prefixes, 0)
+
+	// unsubscribing from a removed topic is a no-op
+	b.Unsubscribe("topic", w1)
+	require.Len(t, b.Topics(), 0)
+
+	t.Run("closed subscribers", func(t *testing.T) {
+		w := b.Subscribe("closed")
+		w.Close()
+		require.Len(t, b.Topics(), 0)
+
+		w1, w2 := b.Subscribe("prefix.*"), b.Subscribe("prefix.*")
+		w1.Close()
+		require.EqualValues(t, []string{"prefix.*"}, b.Topics())
+		w2.Close()
+		require.Len(t, b.Topics(), 0)
+	})
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker()
+	w := b.Subscribe("topic")
+	b.Close()
+
+	_, ok := w.Receive()
+	require.False(t, ok)
+	require.EqualError(t, b.Publish("topic", 1), ErrChannelClosed.Error())
+
+	_, ok = b.Subscribe("topic").Receive()
+	require.False(t, ok)
+	require.Len(t, b.Topics(), 0)
+}
+
+func TestBroker_Concurrency(t *testing.T) {
+	b := NewBroker()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := b.Subscribe("topic", WithOverflow(OverflowDropNewest))
+			b.Publish("topic", true)
+			b.Unsubscribe("topic", w)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, b.Topics(), 0)
+	goleak.VerifyNone(t)
+}
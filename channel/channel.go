@@ -1,70 +1,264 @@
 package channel
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/bloxapp/go-threading/threadsafe"
+	"github.com/pkg/errors"
 )
 
+// ChannelClosed was fired to all waiters when a channel was cancelled.
+//
+// Deprecated: cancelled channels close their waiters instead, see Waiter.Receive, Waiter.Done and ErrClosed
 const ChannelClosed = "channel_closed"
 
+// ErrChannelClosed is returned when firing through a cancelled channel
+var ErrChannelClosed = errors.New("channel is closed")
+
+// Option configures a channel
+type Option func(c *Channel)
+
+// WithReplay makes the channel keep the last n fired objects and fire them to every new waiter when registered.
+// Waiters with a buffer smaller than n get a buffer of n, mailbox waiters queue the replayed objects in their mailbox
+func WithReplay(n int) Option {
+	return func(c *Channel) {
+		c.replaySize = n
+	}
+}
+
+// WithRegistrationOrder makes the channel fire objects to its waiters by their registration order, otherwise the order is random
+func WithRegistrationOrder() Option {
+	return func(c *Channel) {
+		c.ordered = true
+	}
+}
+
+// WithAsyncDelivery registers all waiters with a mailbox of the given size (see WithMailbox), so firing never blocks on slow waiters
+func WithAsyncDelivery(mailboxSize int) Option {
+	return func(c *Channel) {
+		c.waiterOpts = append(c.waiterOpts, WithMailbox(mailboxSize))
+	}
+}
+
+// WithBehavior makes the channel keep its current value, starting with initial, and fire it to every new waiter when registered.
+// Waiters registered WithBufferSize(0) get a buffer of 1, see WithReplay
+func WithBehavior(initial interface{}) Option {
+	return func(c *Channel) {
+		c.replaySize = 1
+		c.replay = []interface{}{initial}
+	}
+}
+
+// Channel fires objects to all of its registered waiters.
+// Fires are delivered one at a time, a fire is passed to all waiters before the next one starts,
+// so every waiter receives the objects in the same global order (some may be missing if dropped by a waiter's overflow policy).
+// The order in which waiters of a single fire are passed the object is random, unless WithRegistrationOrder is used.
 type Channel struct {
-	lock      sync.RWMutex
-	registers map[string]*Waiter
-	cancelled *threadsafe.SafeBool
+	lock       sync.RWMutex
+	fireLock   sync.Mutex // fireLock serializes fires, acquired before lock
+	registers  map[uint64]*Waiter
+	order      []*Waiter // order holds the waiters by registration order, used only if ordered
+	ordered    bool
+	cancelled  *threadsafe.SafeBool
+	replay     []interface{}
+	replaySize int
+	waiterOpts []WaiterOption // waiterOpts are applied to every registered waiter before the Register options
+	stats      channelStats
+	onEmpty    func() // onEmpty is called once DeRegister leaves the channel without waiters, used by Broker to remove empty topics
 }
 
-func New() *Channel {
-	return &Channel{
+func New(opts ...Option) *Channel {
+	c := &Channel{
 		lock:      sync.RWMutex{},
-		registers: make(map[string]*Waiter),
+		registers: make(map[uint64]*Waiter),
 		cancelled: threadsafe.Bool(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// Register will return a waiter if channel is active, nil if it's cancelled
-func (c *Channel) Register() *Waiter {
+// Register will return a waiter, if the channel is cancelled the waiter is already closed.
+// Options can set the waiter's buffer size and overflow policy, a slow waiter with a blocking policy stalls FireToAll.
+// If the channel replays objects, they are all fired to the waiter (even if the channel is cancelled), see WithReplay
+func (c *Channel) Register(opts ...WaiterOption) *Waiter {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	ret := NewWaiter()
+	ret := NewWaiter(append(append([]WaiterOption{}, c.waiterOpts...), opts...)...)
+	ret.parent = c // set before replaying, so replayed objects are counted in the channel's stats
+	c.fireReplay(ret)
+	if c.cancelled.Get() {
+		ret.close()
+		return ret
+	}
 	c.registers[ret.id] = ret
+	if c.ordered {
+		c.order = append(c.order, ret)
+	}
 	return ret
 }
 
+// RegisterContext is like Register but the waiter is closed and deregistered once the context is done
+func (c *Channel) RegisterContext(ctx context.Context, opts ...WaiterOption) *Waiter {
+	ret := c.Register(opts...)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ret.Close()
+		case <-ret.done:
+		}
+	}()
+	return ret
+}
+
+// DeRegister removes the waiter from the channel and closes it, objects fired before deregistering can still be received
 func (c *Channel) DeRegister(waiter *Waiter) {
+	if c.deRegister(waiter) && c.onEmpty != nil {
+		c.onEmpty()
+	}
+}
+
+// deRegister removes and closes the waiter, returns true if it was the channel's last waiter
+func (c *Channel) deRegister(waiter *Waiter) bool {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	if _, ok := c.registers[waiter.id]; ok {
+		waiter.close()
 		delete(c.registers, waiter.id)
+		if c.ordered {
+			c.removeOrdered(waiter)
+		}
+		return len(c.registers) == 0
 	}
+	return false
 }
 
+// DeRegisterAll removes and closes all waiters, the channel can still be registered to and fired
 func (c *Channel) DeRegisterAll() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	c.registers = make(map[string]*Waiter)
+	for _, w := range c.registers {
+		w.close()
+	}
+	c.registers = make(map[uint64]*Waiter)
+	c.order = nil
 }
 
-// FireToAll will fire the object thorough the waiters if not cancelled
-func (c *Channel) FireToAll(obj interface{}) {
+// FireToAll will fire the object thorough the waiters if not cancelled, returns ErrChannelClosed if cancelled
+func (c *Channel) FireToAll(obj interface{}) error {
+	c.fireLock.Lock()
+	defer c.fireLock.Unlock()
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	for _, w := range c.registers {
-		w.Fire(obj)
+
+	if c.cancelled.Get() {
+		return ErrChannelClosed
 	}
+	c.fire(obj)
+	return nil
 }
 
 // FireOnceToAll will fire the object through the waiters if not cancelled, will cancel channel after
-func (c *Channel) FireOnceToAll(obj interface{}) {
-	c.FireToAll(obj)
-	c.CancelAll()
+func (c *Channel) FireOnceToAll(obj interface{}) error {
+	c.fireLock.Lock()
+	defer c.fireLock.Unlock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.cancelled.Get() {
+		return ErrChannelClosed
+	}
+	c.fire(obj)
+	c.cancel()
+	return nil
 }
 
-// CancelAll will fire nil to all waiters and will not fire any obj again
+// CancelAll will close all waiters (after they receive the objects already fired) and will not fire any obj again
 func (c *Channel) CancelAll() {
+	c.fireLock.Lock()
+	defer c.fireLock.Unlock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.cancelled.Get() {
+		return
+	}
+	c.cancel()
+}
+
+// IsClosed returns true if the channel was cancelled
+func (c *Channel) IsClosed() bool {
+	return c.cancelled.Get()
+}
+
+// cancel marks the channel as cancelled, closes and deregisters all waiters
+// not thread safe, should be called while holding the lock
+func (c *Channel) cancel() {
 	c.cancelled.Set(true)
-	c.FireToAll(ChannelClosed)
+	for _, w := range c.registers {
+		w.close()
+	}
+	c.registers = make(map[uint64]*Waiter)
+	c.order = nil
+}
+
+// removeOrdered removes the waiter from the registration order
+// not thread safe, should be called while holding the lock
+func (c *Channel) removeOrdered(waiter *Waiter) {
+	for i, w := range c.order {
+		if w == waiter {
+			c.order = append(c.order[:i:i], c.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// fireReplay fires the replayed objects to a new waiter without blocking, its buffer is grown to fit them unless it has a mailbox
+// not thread safe, should be called while holding the lock
+func (c *Channel) fireReplay(w *Waiter) {
+	if len(c.replay) == 0 {
+		return
+	}
+	if w.mailbox == nil && cap(w.c) < c.replaySize {
+		w.c = make(chan interface{}, c.replaySize)
+	}
+	for _, obj := range c.replay {
+		if w.filter != nil && !w.filter(obj) {
+			continue
+		}
+		if w.mailbox != nil {
+			w.mailbox.queue(obj)
+		} else {
+			w.c <- obj
+		}
+		w.countDelivered()
+	}
+}
+
+// fire passes obj to all waiters
+// not thread safe, should be called while holding the fire lock and the (read) lock
+func (c *Channel) fire(obj interface{}) {
+	atomic.AddUint64(&c.stats.fired, 1)
+	if c.replaySize > 0 {
+		c.replay = append(c.replay, obj)
+		if len(c.replay) > c.replaySize {
+			c.replay = c.replay[len(c.replay)-c.replaySize:]
+		}
+	}
+
+	if c.ordered {
+		for _, w := range c.order {
+			w.Fire(obj)
+		}
+		return
+	}
+	for _, w := range c.registers {
+		w.Fire(obj)
+	}
 }
@@ -1,6 +1,7 @@
 package channel
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -22,7 +23,7 @@ func TestChannelLeaks(t *testing.T) {
 			go func(c *Channel) {
 				c.FireToAll("test")
 			}(c)
-			c.Register().Wait()
+			c.Register().Receive()
 			wg.Done()
 		}
 
@@ -47,19 +48,23 @@ func TestChannelLeaks(t *testing.T) {
 func TestChannel_RegisterAndFire(t *testing.T) {
 	c := New()
 
+	wg := sync.WaitGroup{}
 	fired := make([]*threadsafe.SafeBool, 0)
 	for i := 0; i < 100; i++ {
 		w := c.Register()
 		firedBool := threadsafe.Bool()
 		fired = append(fired, firedBool)
+		wg.Add(1)
 		go func(w *Waiter, firedBool *threadsafe.SafeBool) {
-			w.Wait()
+			defer wg.Done()
+			w.Receive()
 			firedBool.Set(true)
 		}(w, firedBool)
 	}
 
 	time.Sleep(time.Millisecond * 25)
 	c.FireToAll(true)
+	wg.Wait()
 
 	// verify
 	for i, b := range fired {
@@ -93,10 +98,10 @@ func TestChannel_FireOnceToAll(t *testing.T) {
 		firedObj := threadsafe.Any()
 		fired = append(fired, firedObj)
 		go func(w *Waiter, firedObj *threadsafe.AnyObj) {
-			obj := w.Wait()
+			obj, _ := w.Receive()
 			require.True(t, obj.(bool))
-			obj = w.Wait()
-			firedObj.Set(obj)
+			_, ok := w.Receive()
+			firedObj.Set(ok)
 		}(w, firedObj)
 	}
 	time.Sleep(time.Millisecond * 100)
@@ -106,7 +111,344 @@ func TestChannel_FireOnceToAll(t *testing.T) {
 	// verify
 	for i, b := range fired {
 		t.Run(fmt.Sprintf("waiter: %d", i), func(t *testing.T) {
-			require.EqualValues(t, b.Get(), ChannelClosed)
+			require.EqualValues(t, false, b.Get()) // closed
 		})
 	}
 }
+
+func TestChannel_SlowWaiter(t *testing.T) {
+	c := New()
+	slow := c.Register(WithBufferSize(1), WithOverflow(OverflowDropOldest))
+	fast := c.Register(WithBufferSize(100))
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			c.FireToAll(i)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "FireToAll blocked on a slow waiter")
+	}
+
+	require.EqualValues(t, 9, slow.Dropped())
+	require.EqualValues(t, 9, receive(t, slow))
+	for i := 0; i < 10; i++ {
+		require.EqualValues(t, i, receive(t, fast))
+	}
+	require.EqualValues(t, 0, fast.Dropped())
+}
+
+func TestChannel_Closed(t *testing.T) {
+	c := New()
+	w := c.Register()
+	require.False(t, c.IsClosed())
+	require.NoError(t, c.FireToAll(true))
+	require.True(t, receive(t, w).(bool))
+
+	c.CancelAll()
+	require.True(t, c.IsClosed())
+	_, ok := w.Receive()
+	require.False(t, ok)
+	require.Len(t, c.registers, 0)
+
+	t.Run("fire after close", func(t *testing.T) {
+		require.EqualError(t, c.FireToAll(true), ErrChannelClosed.Error())
+		require.EqualError(t, c.FireOnceToAll(true), ErrChannelClosed.Error())
+		require.EqualValues(t, ErrClosed, w.WaitWithTimeout(time.Millisecond*10))
+	})
+
+	t.Run("register after close", func(t *testing.T) {
+		w := c.Register()
+		_, ok := w.Receive()
+		require.False(t, ok)
+		require.Len(t, c.registers, 0)
+	})
+
+	t.Run("cancel twice", func(t *testing.T) {
+		c.CancelAll()
+		require.True(t, c.IsClosed())
+	})
+}
+
+func TestChannel_CancelAllDeliversFired(t *testing.T) {
+	c := New()
+	w := c.Register()
+	require.NoError(t, c.FireOnceToAll(ChannelClosed))
+
+	// a payload equal to the deprecated sentinel is a normal value
+	obj, ok := w.Receive()
+	require.True(t, ok)
+	require.EqualValues(t, ChannelClosed, obj)
+
+	_, ok = w.Receive()
+	require.False(t, ok)
+}
+
+func TestChannel_Replay(t *testing.T) {
+	t.Run("last n", func(t *testing.T) {
+		c := New(WithReplay(3))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, c.FireToAll(i))
+		}
+
+		w := c.Register()
+		require.EqualValues(t, 2, receive(t, w))
+		require.EqualValues(t, 3, receive(t, w))
+		require.EqualValues(t, 4, receive(t, w))
+		require.EqualValues(t, ContextDoneErr, w.WaitWithTimeout(time.Millisecond*10))
+
+		c.FireToAll(5)
+		require.EqualValues(t, 5, receive(t, w))
+	})
+
+	t.Run("more than the waiter's buffer", func(t *testing.T) {
+		c := New(WithReplay(QueueSize * 2))
+		for i := 0; i < QueueSize*2; i++ {
+			c.FireToAll(i)
+		}
+
+		w := c.Register()
+		require.Len(t, w.DrainAll(), QueueSize*2)
+
+		// the buffer is grown to fit the replayed objects
+		w = c.Register(WithBufferSize(1))
+		objs := w.DrainAll()
+		require.Len(t, objs, QueueSize*2)
+		require.EqualValues(t, 0, objs[0])
+		require.EqualValues(t, QueueSize*2-1, objs[len(objs)-1])
+	})
+
+	t.Run("mailbox", func(t *testing.T) {
+		c := New(WithReplay(10))
+		for i := 0; i < 10; i++ {
+			c.FireToAll(i)
+		}
+
+		// replayed objects are queued in the mailbox regardless of its size
+		for _, size := range []int{0, 2} {
+			w := c.Register(WithBufferSize(0), WithMailbox(size))
+			require.EqualValues(t, []interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, w.DrainAll())
+			w.Close()
+		}
+	})
+
+	t.Run("no buffer", func(t *testing.T) {
+		c := New(WithReplay(1))
+		c.FireToAll("test")
+		w := c.Register(WithBufferSize(0))
+		obj, ok := w.TryWait()
+		require.True(t, ok)
+		require.EqualValues(t, "test", obj)
+	})
+
+	t.Run("after fire once", func(t *testing.T) {
+		c := New(WithReplay(1))
+		c.FireOnceToAll("result")
+
+		w := c.Register()
+		require.EqualValues(t, "result", receive(t, w))
+		_, ok := w.Receive()
+		require.False(t, ok)
+	})
+
+	t.Run("no replay by default", func(t *testing.T) {
+		c := New()
+		c.FireToAll("test")
+		require.EqualValues(t, ContextDoneErr, c.Register().WaitWithTimeout(time.Millisecond*10))
+	})
+}
+
+func TestChannel_Behavior(t *testing.T) {
+	c := New(WithBehavior("initial"))
+	require.EqualValues(t, "initial", receive(t, c.Register()))
+
+	c.FireToAll("first")
+	c.FireToAll("second")
+	w := c.Register()
+	require.EqualValues(t, "second", receive(t, w))
+	require.EqualValues(t, ContextDoneErr, w.WaitWithTimeout(time.Millisecond*10))
+
+	w = c.Register(WithBufferSize(0), WithMailbox(0))
+	require.EqualValues(t, "second", receive(t, w))
+	w.Close()
+	w = c.Register(WithBufferSize(0))
+	require.EqualValues(t, "second", receive(t, w))
+
+	typed := NewTyped[int](WithBehavior(7))
+	obj, ok := typed.Register().Receive()
+	require.True(t, ok)
+	require.EqualValues(t, 7, obj)
+}
+
+func TestChannel_RegisterContext(t *testing.T) {
+	c := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := c.RegisterContext(ctx)
+	require.EqualValues(t, 1, c.Len())
+
+	c.FireToAll(1)
+	cancel()
+	<-w.Done()
+	require.Eventually(t, func() bool {
+		return c.Len() == 0
+	}, time.Second, time.Millisecond)
+
+	// objects fired before the context was done are still received
+	require.EqualValues(t, 1, receive(t, w))
+	_, ok := w.Receive()
+	require.False(t, ok)
+
+	t.Run("no leaks", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			c := New()
+			ctx, cancel := context.WithCancel(context.Background())
+			c.RegisterContext(ctx)
+			if i%2 == 0 {
+				cancel()
+			} else {
+				c.CancelAll()
+				cancel()
+			}
+		}
+		time.Sleep(time.Millisecond * 25)
+		goleak.VerifyNone(t)
+	})
+}
+
+func TestWaiter_Close(t *testing.T) {
+	c := New()
+	w1 := c.Register()
+	w2 := c.Register()
+
+	w1.Close()
+	require.Len(t, c.registers, 1)
+	require.NoError(t, c.FireToAll(1))
+	require.EqualValues(t, 1, receive(t, w2))
+	_, ok := w1.Receive()
+	require.False(t, ok)
+
+	// closing twice or closing an unregistered waiter is safe
+	w1.Close()
+	NewWaiter().Close()
+}
+
+func TestWaiter_CloseUnblocksFireToAll(t *testing.T) {
+	c := New()
+	w := c.Register(WithBufferSize(0))
+	go func() {
+		time.Sleep(time.Millisecond * 25)
+		w.Close()
+	}()
+	require.NoError(t, c.FireToAll(1))
+	require.Eventually(t, func() bool {
+		return c.Len() == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestChannel_GlobalOrder(t *testing.T) {
+	const firers, firesPerFirer, waiters = 8, 100, 10
+
+	c := New()
+	ws := make([]*Waiter, waiters)
+	for i := range ws {
+		ws[i] = c.Register(WithBufferSize(firers * firesPerFirer))
+	}
+
+	wg := sync.WaitGroup{}
+	for f := 0; f < firers; f++ {
+		wg.Add(1)
+		go func(f int) {
+			defer wg.Done()
+			for i := 0; i < firesPerFirer; i++ {
+				require.NoError(t, c.FireToAll(f*firesPerFirer+i))
+			}
+		}(f)
+	}
+	wg.Wait()
+	c.CancelAll()
+
+	// every waiter should receive all fires in the same order
+	var expected []interface{}
+	for i, w := range ws {
+		received := make([]interface{}, 0, firers*firesPerFirer)
+		for {
+			obj, ok := w.Receive()
+			if !ok {
+				break
+			}
+			received = append(received, obj)
+		}
+		require.Len(t, received, firers*firesPerFirer)
+		if i == 0 {
+			expected = received
+			continue
+		}
+		require.EqualValues(t, expected, received)
+	}
+}
+
+func TestChannel_RegistrationOrder(t *testing.T) {
+	c := New(WithRegistrationOrder())
+
+	lock := sync.Mutex{}
+	delivered := make([]int, 0)
+	ws := make([]*Waiter, 0)
+	for i := 0; i < 20; i++ {
+		idx := i
+		ws = append(ws, c.Register(WithFilter(func(obj interface{}) bool {
+			lock.Lock()
+			defer lock.Unlock()
+			delivered = append(delivered, idx)
+			return false
+		})))
+	}
+	c.DeRegister(ws[5])
+	ws[10].Close()
+
+	for fire := 0; fire < 3; fire++ {
+		delivered = delivered[:0]
+		require.NoError(t, c.FireToAll(true))
+
+		expected := make([]int, 0)
+		for i := 0; i < 20; i++ {
+			if i != 5 && i != 10 {
+				expected = append(expected, i)
+			}
+		}
+		require.EqualValues(t, expected, delivered)
+	}
+}
+
+func TestChannel_ConcurrentRegisterAndFire(t *testing.T) {
+	c := New(WithRegistrationOrder(), WithReplay(2))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			w := c.Register(WithOverflow(OverflowDropOldest))
+			c.FireToAll(true)
+			w.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			c.FireToAll(false)
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 0, c.Len())
+}
+
+func BenchmarkChannel_Register(b *testing.B) {
+	c := New()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Register().Close()
+	}
+}
@@ -0,0 +1,172 @@
+package channel
+
+import (
+	"sync"
+)
+
+// WithMailbox makes Fire never block, fired objects are queued in a mailbox which is drained into the waiter by its own go routine.
+// size bounds the mailbox (0 is unbounded), when full the oldest queued object is dropped, or the newest if OverflowDropNewest is set.
+// The go routine runs until the waiter is closed
+func WithMailbox(size int) WaiterOption {
+	return func(w *Waiter) {
+		if size < 0 {
+			size = 0
+		}
+		w.mailbox = &mailbox{
+			size:    size,
+			notify:  make(chan struct{}, 1),
+			stopped: make(chan struct{}),
+		}
+	}
+}
+
+// Backpressure holds a waiter's mailbox metrics
+type Backpressure struct {
+	// Queued is the number of objects in the mailbox
+	Queued int
+	// MaxQueued is the highest number of objects the mailbox ever held
+	MaxQueued int
+	// Dropped is the number of objects dropped by the waiter
+	Dropped uint64
+}
+
+// mailbox is a thread safe FIFO of fired objects
+type mailbox struct {
+	lock      sync.Mutex
+	items     []interface{}
+	size      int
+	maxQueued int
+	held      bool          // held is true while the forwarding go routine holds a popped object
+	notify    chan struct{} // notify signals the forwarding go routine a new object was queued
+	stopped   chan struct{} // stopped is closed once the forwarding go routine returns
+}
+
+// push queues obj, returns false if an object was dropped
+func (m *mailbox) push(obj interface{}, dropNewest bool) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	dropped := false
+	if m.size > 0 && len(m.items) >= m.size {
+		if dropNewest {
+			return false
+		}
+		m.items = m.items[1:]
+		dropped = true
+	}
+	m.items = append(m.items, obj)
+	if len(m.items) > m.maxQueued {
+		m.maxQueued = len(m.items)
+	}
+
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+	return !dropped
+}
+
+// queue is like push but ignores the mailbox size
+func (m *mailbox) queue(obj interface{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.items = append(m.items, obj)
+	if len(m.items) > m.maxQueued {
+		m.maxQueued = len(m.items)
+	}
+
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pushFront returns an object which couldn't be forwarded to the front of the mailbox
+func (m *mailbox) pushFront(obj interface{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.items = append([]interface{}{obj}, m.items...)
+	m.held = false
+}
+
+// take is like pop but marks the object as held until forwarded or returned with pushFront
+func (m *mailbox) take() (interface{}, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.items) == 0 {
+		return nil, false
+	}
+	obj := m.items[0]
+	m.items[0] = nil
+	m.items = m.items[1:]
+	m.held = true
+	return obj, true
+}
+
+// forwarded clears the held object once passed to the waiter
+func (m *mailbox) forwarded() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.held = false
+}
+
+// pending returns the number of queued objects including the held one
+func (m *mailbox) pending() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.held {
+		return len(m.items) + 1
+	}
+	return len(m.items)
+}
+
+func (m *mailbox) pop() (interface{}, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.items) == 0 {
+		return nil, false
+	}
+	obj := m.items[0]
+	m.items[0] = nil
+	m.items = m.items[1:]
+	return obj, true
+}
+
+func (m *mailbox) stats() (int, int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.items), m.maxQueued
+}
+
+// forward drains the mailbox into the waiter until the waiter is closed
+func (m *mailbox) forward(w *Waiter) {
+	defer close(m.stopped)
+
+	for {
+		obj, ok := m.take()
+		if !ok {
+			select {
+			case <-m.notify:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+
+		select {
+		case w.c <- obj:
+			m.forwarded()
+		case <-w.done:
+			// keep it for receiving after closing
+			m.pushFront(obj)
+			return
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailbox_NeverBlocks(t *testing.T) {
+	c := New(WithAsyncDelivery(0))
+	slow := c.Register(WithBufferSize(0))
+	fast := c.Register()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			c.FireToAll(i)
+		}
+		close(done)
+	}()
+
+	go func() {
+		for i := 0; i < 1000; i++ {
+			require.EqualValues(t, i, receive(t, fast))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "publisher blocked on a slow waiter")
+	}
+
+	bp := slow.Backpressure()
+	require.Greater(t, bp.Queued, 900)
+	require.GreaterOrEqual(t, bp.MaxQueued, bp.Queued)
+	require.EqualValues(t, 0, bp.Dropped)
+
+	// the slow waiter still receives everything in order
+	for i := 0; i < 1000; i++ {
+		require.EqualValues(t, i, receive(t, slow))
+	}
+	require.EqualValues(t, 0, slow.Backpressure().Queued)
+
+	c.CancelAll()
+	goleak.VerifyNone(t)
+}
+
+func TestMailbox_Bounded(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(0), WithMailbox(2))
+		defer w.Close()
+		for i := 0; i < 5; i++ {
+			w.Fire(i)
+		}
+
+		// the forwarding go routine might hold one object while waiting to pass it
+		require.Eventually(t, func() bool {
+			return w.Dropped() >= 2
+		}, time.Second, time.Millisecond)
+		last := -1
+		for i := 0; i < 5-int(w.Dropped()); i++ {
+			obj := receive(t, w).(int)
+			require.Greater(t, obj, last)
+			last = obj
+		}
+		require.EqualValues(t, 4, last)
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(1), WithMailbox(1), WithOverflow(OverflowDropNewest))
+		defer w.Close()
+		require.True(t, w.Fire(0))
+		require.Eventually(t, func() bool {
+			return w.Backpressure().Queued == 0
+		}, time.Second, time.Millisecond)
+		require.True(t, w.Fire(1))
+		time.Sleep(time.Millisecond * 10)
+		require.True(t, w.Fire(2))
+		require.False(t, w.Fire(3))
+
+		require.EqualValues(t, 0, receive(t, w))
+		require.EqualValues(t, 1, receive(t, w))
+		require.EqualValues(t, 2, receive(t, w))
+		require.EqualValues(t, 1, w.Backpressure().Dropped)
+	})
+}
+
+func TestMailbox_ReceiveAfterClose(t *testing.T) {
+	c := New(WithAsyncDelivery(0))
+	w := c.Register(WithBufferSize(1))
+	for i := 0; i < 10; i++ {
+		c.FireToAll(i)
+	}
+	c.CancelAll()
+
+	for i := 0; i < 10; i++ {
+		obj, ok := w.Receive()
+		require.True(t, ok)
+		require.EqualValues(t, i, obj)
+	}
+	_, ok := w.Receive()
+	require.False(t, ok)
+	goleak.VerifyNone(t)
+}
+
+func TestMailbox_Leaks(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		w := NewWaiter(WithMailbox(0))
+		w.Fire(i)
+		w.Close()
+	}
+	for i := 0; i < 50; i++ {
+		c := New(WithAsyncDelivery(10))
+		c.Register()
+		c.FireOnceToAll(i)
+	}
+	time.Sleep(time.Millisecond * 10)
+	goleak.VerifyNone(t)
+}
+
+func TestMailbox_DeRegisterLeaks(t *testing.T) {
+	t.Run("deregister", func(t *testing.T) {
+		c := New(WithAsyncDelivery(10))
+		for i := 0; i < 50; i++ {
+			w := c.Register()
+			require.NoError(t, c.FireToAll(i))
+			c.DeRegister(w)
+
+			// objects fired before deregistering can still be received
+			obj, ok := w.Receive()
+			require.True(t, ok)
+			require.EqualValues(t, i, obj)
+			_, ok = w.Receive()
+			require.False(t, ok)
+		}
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("deregister all", func(t *testing.T) {
+		c := New()
+		for i := 0; i < 50; i++ {
+			c.Register(WithMailbox(0))
+		}
+		require.NoError(t, c.FireToAll(true))
+		c.DeRegisterAll()
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		b := NewBroker()
+		for i := 0; i < 50; i++ {
+			w := b.Subscribe("topic", WithMailbox(0))
+			require.NoError(t, b.Publish("topic", i))
+			b.Unsubscribe("topic", w)
+		}
+		require.Len(t, b.Topics(), 0)
+		goleak.VerifyNone(t)
+	})
+}
@@ -0,0 +1,240 @@
+package channel
+
+import (
+	"context"
+	"time"
+)
+
+// Operators transform the objects received by waiters and pass them to a new waiter.
+// Every operator runs a go routine which stops when the context is done, its source waiters are closed
+// or the returned waiter is closed. Once stopped the source waiters are closed (and deregistered)
+// and the returned waiter is closed after receiving the objects already passed to it.
+
+// Merge passes the objects of all waiters to the returned waiter, it's closed once all waiters are closed
+func Merge(ctx context.Context, waiters ...*Waiter) *Waiter {
+	out := NewWaiter()
+	go func() {
+		defer closeAll(out, waiters...)
+
+		open := append([]*Waiter{}, waiters...)
+		for len(open) > 0 {
+			idx, obj, err := selectUntil(ctx, out.done, open...)
+			switch {
+			case err == ErrClosed:
+				open = append(open[:idx:idx], open[idx+1:]...)
+			case err != nil: // context done or out closed
+				return
+			default:
+				if !emit(ctx, out, obj) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Map passes the result of fn for every object
+func Map(ctx context.Context, src *Waiter, fn func(obj interface{}) interface{}) *Waiter {
+	return operate(ctx, src, func(out *Waiter, obj interface{}) bool {
+		return emit(ctx, out, fn(obj))
+	})
+}
+
+// Filter passes only the objects for which fn returns true
+func Filter(ctx context.Context, src *Waiter, fn func(obj interface{}) bool) *Waiter {
+	return operate(ctx, src, func(out *Waiter, obj interface{}) bool {
+		if !fn(obj) {
+			return true
+		}
+		return emit(ctx, out, obj)
+	})
+}
+
+// Take passes the first n objects and then stops
+func Take(ctx context.Context, src *Waiter, n int) *Waiter {
+	taken := 0
+	if n <= 0 {
+		out := NewWaiter()
+		closeAll(out, src)
+		return out
+	}
+	return operate(ctx, src, func(out *Waiter, obj interface{}) bool {
+		taken++
+		return emit(ctx, out, obj) && taken < n
+	})
+}
+
+// Throttle passes an object and then ignores objects for duration d
+func Throttle(ctx context.Context, src *Waiter, d time.Duration) *Waiter {
+	var last time.Time
+	return operate(ctx, src, func(out *Waiter, obj interface{}) bool {
+		now := time.Now()
+		if !last.IsZero() && now.Sub(last) < d {
+			return true
+		}
+		last = now
+		return emit(ctx, out, obj)
+	})
+}
+
+// Debounce passes an object only after d passed without receiving another one, the pending object is passed when src is closed
+func Debounce(ctx context.Context, src *Waiter, d time.Duration) *Waiter {
+	out := NewWaiter()
+	go func() {
+		defer closeAll(out, src)
+
+		var pending interface{}
+		hasPending := false
+		t := time.NewTimer(d)
+		t.Stop()
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-out.done:
+				return
+			case <-t.C:
+				hasPending = false
+				if !emit(ctx, out, pending) {
+					return
+				}
+			case obj := <-src.c:
+				pending, hasPending = obj, true
+				resetTimer(t, d)
+			case <-src.done:
+				for {
+					obj, ok := src.receiveClosed()
+					if !ok {
+						break
+					}
+					pending, hasPending = obj, true
+				}
+				if hasPending {
+					emit(ctx, out, pending)
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Buffer passes the objects in batches ([]interface{}) of n objects, or every d if d > 0, whichever comes first.
+// Empty batches are not passed, the last batch is passed when src is closed
+func Buffer(ctx context.Context, src *Waiter, n int, d time.Duration) *Waiter {
+	out := NewWaiter()
+	go func() {
+		defer closeAll(out, src)
+
+		batch := make([]interface{}, 0)
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+			ret := batch
+			batch = make([]interface{}, 0)
+			return emit(ctx, out, ret)
+		}
+
+		var tick <-chan time.Time
+		if d > 0 {
+			ticker := time.NewTicker(d)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-out.done:
+				return
+			case <-tick:
+				if !flush() {
+					return
+				}
+			case obj := <-src.c:
+				batch = append(batch, obj)
+				if n > 0 && len(batch) >= n && !flush() {
+					return
+				}
+			case <-src.done:
+				for {
+					obj, ok := src.receiveClosed()
+					if !ok {
+						break
+					}
+					batch = append(batch, obj)
+					if n > 0 && len(batch) >= n && !flush() {
+						return
+					}
+				}
+				flush()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// operate runs a go routine passing every object received from src to handle, until handle returns false or the operator stops
+func operate(ctx context.Context, src *Waiter, handle func(out *Waiter, obj interface{}) bool) *Waiter {
+	out := NewWaiter()
+	go func() {
+		defer closeAll(out, src)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-out.done:
+				return
+			case obj := <-src.c:
+				if !handle(out, obj) {
+					return
+				}
+			case <-src.done:
+				for {
+					obj, ok := src.receiveClosed()
+					if !ok || !handle(out, obj) {
+						return
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// emit passes obj to out, returns false if the context is done or out is closed
+func emit(ctx context.Context, out *Waiter, obj interface{}) bool {
+	select {
+	case out.c <- obj:
+		return true
+	case <-out.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// closeAll closes out and the source waiters
+func closeAll(out *Waiter, srcs ...*Waiter) {
+	for _, src := range srcs {
+		src.Close()
+	}
+	out.close()
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
@@ -0,0 +1,182 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+// receiveAll receives from w until it's closed
+func receiveAll(t *testing.T, w *Waiter) []interface{} {
+	ret := make([]interface{}, 0)
+	for {
+		obj, err := w.WaitTimeout(time.Second)
+		if err == ErrClosed {
+			return ret
+		}
+		require.NoError(t, err)
+		ret = append(ret, obj)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	c1, c2 := New(), New()
+	out := Merge(context.Background(), c1.Register(), c2.Register())
+
+	go func() {
+		c1.FireToAll(1)
+		c2.FireToAll(2)
+		c1.FireToAll(3)
+		c1.CancelAll()
+		c2.CancelAll()
+	}()
+
+	require.ElementsMatch(t, []interface{}{1, 2, 3}, receiveAll(t, out))
+	goleak.VerifyNone(t)
+}
+
+func TestMapFilter(t *testing.T) {
+	c := New()
+	src := c.Register(WithBufferSize(10))
+	even := Filter(context.Background(), src, func(obj interface{}) bool {
+		return obj.(int)%2 == 0
+	})
+	out := Map(context.Background(), even, func(obj interface{}) interface{} {
+		return obj.(int) * 10
+	})
+
+	for i := 0; i < 6; i++ {
+		c.FireToAll(i)
+	}
+	c.CancelAll()
+
+	require.EqualValues(t, []interface{}{0, 20, 40}, receiveAll(t, out))
+	goleak.VerifyNone(t)
+}
+
+func TestTake(t *testing.T) {
+	c := New()
+	src := c.Register(WithBufferSize(10))
+	out := Take(context.Background(), src, 2)
+
+	for i := 0; i < 5; i++ {
+		c.FireToAll(i)
+	}
+
+	require.EqualValues(t, []interface{}{0, 1}, receiveAll(t, out))
+	// the source is deregistered once done
+	require.Eventually(t, func() bool {
+		return c.Len() == 0
+	}, time.Second, time.Millisecond)
+	goleak.VerifyNone(t)
+
+	_, ok := Take(context.Background(), NewWaiter(), 0).Receive()
+	require.False(t, ok)
+}
+
+func TestThrottle(t *testing.T) {
+	src := NewWaiter(WithBufferSize(10))
+	out := Throttle(context.Background(), src, time.Millisecond*50)
+
+	src.Fire(1)
+	src.Fire(2)
+	time.Sleep(time.Millisecond * 75)
+	src.Fire(3)
+	src.Fire(4)
+	src.Close()
+
+	require.EqualValues(t, []interface{}{1, 3}, receiveAll(t, out))
+	goleak.VerifyNone(t)
+}
+
+func TestDebounce(t *testing.T) {
+	src := NewWaiter(WithBufferSize(10))
+	out := Debounce(context.Background(), src, time.Millisecond*30)
+
+	src.Fire(1)
+	src.Fire(2)
+	time.Sleep(time.Millisecond * 60)
+	src.Fire(3)
+	time.Sleep(time.Millisecond * 10)
+	src.Fire(4)
+	time.Sleep(time.Millisecond * 60)
+	src.Fire(5)
+	src.Close()
+
+	require.EqualValues(t, []interface{}{2, 4, 5}, receiveAll(t, out))
+	goleak.VerifyNone(t)
+}
+
+func TestBuffer(t *testing.T) {
+	t.Run("by count", func(t *testing.T) {
+		src := NewWaiter(WithBufferSize(10))
+		out := Buffer(context.Background(), src, 2, 0)
+		for i := 0; i < 5; i++ {
+			src.Fire(i)
+		}
+		src.Close()
+
+		require.EqualValues(t, []interface{}{
+			[]interface{}{0, 1},
+			[]interface{}{2, 3},
+			[]interface{}{4},
+		}, receiveAll(t, out))
+	})
+
+	t.Run("by time", func(t *testing.T) {
+		src := NewWaiter(WithBufferSize(10))
+		out := Buffer(context.Background(), src, 0, time.Millisecond*30)
+		src.Fire(1)
+		src.Fire(2)
+		require.EqualValues(t, []interface{}{1, 2}, receive(t, out))
+
+		src.Fire(3)
+		require.EqualValues(t, []interface{}{3}, receive(t, out))
+		src.Close()
+		require.Len(t, receiveAll(t, out), 0)
+	})
+
+	goleak.VerifyNone(t)
+}
+
+func TestOperatorsShutdown(t *testing.T) {
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c := New()
+		outs := []*Waiter{
+			Merge(ctx, c.Register(), c.Register()),
+			Map(ctx, c.Register(), func(obj interface{}) interface{} { return obj }),
+			Filter(ctx, c.Register(), func(obj interface{}) bool { return true }),
+			Take(ctx, c.Register(), 10),
+			Throttle(ctx, c.Register(), time.Second),
+			Debounce(ctx, c.Register(), time.Second),
+			Buffer(ctx, c.Register(), 10, time.Second),
+		}
+		cancel()
+
+		for _, out := range outs {
+			<-out.Done()
+		}
+		require.Eventually(t, func() bool {
+			return c.Len() == 0
+		}, time.Second, time.Millisecond)
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("output closed", func(t *testing.T) {
+		c := New()
+		out := Map(context.Background(), c.Register(), func(obj interface{}) interface{} { return obj })
+		for i := 0; i < QueueSize*2; i++ { // fill the output so the operator blocks
+			c.FireToAll(i)
+		}
+		out.Close()
+		require.Eventually(t, func() bool {
+			return c.Len() == 0
+		}, time.Second, time.Millisecond)
+		goleak.VerifyNone(t)
+	})
+}
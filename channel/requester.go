@@ -0,0 +1,137 @@
+package channel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Request is fired through the requests channel of a Requester, responders reply by firing Request.Reply through the replies channel
+type Request struct {
+	// ID correlates the request with its replies
+	ID      uint64
+	Payload interface{}
+}
+
+// Reply returns a reply to the request
+func (r Request) Reply(payload interface{}) Reply {
+	return Reply{
+		ID:      r.ID,
+		Payload: payload,
+	}
+}
+
+// Reply is fired through the replies channel of a Requester
+type Reply struct {
+	// ID is the ID of the request replied to
+	ID      uint64
+	Payload interface{}
+}
+
+// Requester fires requests on one channel and routes the matching replies from another channel back to the caller.
+// Replies that don't match a pending request are ignored
+type Requester struct {
+	requests *Channel
+	router   *Waiter
+	lock     sync.RWMutex
+	pending  map[uint64]*Waiter
+}
+
+// NewRequester returns a Requester and starts routing replies, Close should be called to stop routing
+func NewRequester(requests, replies *Channel) *Requester {
+	r := &Requester{
+		requests: requests,
+		router:   replies.Register(WithMailbox(0)),
+		lock:     sync.RWMutex{},
+		pending:  make(map[uint64]*Waiter),
+	}
+	go r.route()
+	return r
+}
+
+// Request fires a request with the payload and returns the payload of its first reply, ContextDoneErr if the context is done first
+func (r *Requester) Request(ctx context.Context, payload interface{}) (interface{}, error) {
+	w, err := r.fire(payload, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer r.done(w)
+
+	return w.WaitContext(ctx)
+}
+
+// RequestTimeout is like Request with a timeout
+func (r *Requester) RequestTimeout(duration time.Duration, payload interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	return r.Request(ctx, payload)
+}
+
+// Gather fires a request with the payload and collects the payloads of up to n replies.
+// If the context is done before n replies arrived, the replies collected so far are returned with ContextDoneErr
+func (r *Requester) Gather(ctx context.Context, payload interface{}, n int) ([]interface{}, error) {
+	w, err := r.fire(payload, n)
+	if err != nil {
+		return nil, err
+	}
+	defer r.done(w)
+
+	ret := make([]interface{}, 0, n)
+	for len(ret) < n {
+		obj, err := w.WaitContext(ctx)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, obj)
+	}
+	return ret, nil
+}
+
+// Close stops routing replies, pending requests will wait until their context is done
+func (r *Requester) Close() {
+	r.router.Close()
+}
+
+// fire registers a pending waiter for up to n replies and fires the request
+func (r *Requester) fire(payload interface{}, n int) (*Waiter, error) {
+	w := NewWaiter(WithBufferSize(n), WithOverflow(OverflowDropNewest))
+
+	r.lock.Lock()
+	r.pending[w.ID()] = w
+	r.lock.Unlock()
+
+	if err := r.requests.FireToAll(Request{ID: w.ID(), Payload: payload}); err != nil {
+		r.done(w)
+		return nil, err
+	}
+	return w, nil
+}
+
+// done removes a pending waiter
+func (r *Requester) done(w *Waiter) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.pending, w.ID())
+	w.close()
+}
+
+// route passes replies to their pending waiters until closed
+func (r *Requester) route() {
+	for {
+		obj, ok := r.router.Receive()
+		if !ok {
+			return
+		}
+		reply, ok := obj.(Reply)
+		if !ok {
+			continue
+		}
+
+		r.lock.RLock()
+		if w, ok := r.pending[reply.ID]; ok {
+			w.Fire(reply.Payload)
+		}
+		r.lock.RUnlock()
+	}
+}
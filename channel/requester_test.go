@@ -0,0 +1,117 @@
+package channel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+// respond replies to every request on requests with fn's result until the returned waiter is closed
+func respond(requests, replies *Channel, fn func(payload interface{}) interface{}) *Waiter {
+	w := requests.Register(WithMailbox(0))
+	go func() {
+		for {
+			obj, ok := w.Receive()
+			if !ok {
+				return
+			}
+			req := obj.(Request)
+			replies.FireToAll(req.Reply(fn(req.Payload)))
+		}
+	}()
+	return w
+}
+
+func TestRequester_Request(t *testing.T) {
+	requests, replies := New(), New()
+	responder := respond(requests, replies, func(payload interface{}) interface{} {
+		return payload.(int) * 2
+	})
+	r := NewRequester(requests, replies)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := r.RequestTimeout(time.Second, i)
+			require.NoError(t, err)
+			require.EqualValues(t, i*2, res)
+		}(i)
+	}
+	wg.Wait()
+	require.Len(t, r.pending, 0)
+
+	r.Close()
+	responder.Close()
+	goleak.VerifyNone(t)
+}
+
+func TestRequester_Timeout(t *testing.T) {
+	requests, replies := New(), New()
+	r := NewRequester(requests, replies)
+	defer r.Close()
+
+	// unrelated replies are ignored
+	replies.FireToAll(Reply{ID: 0, Payload: true})
+	replies.FireToAll("not a reply")
+
+	res, err := r.RequestTimeout(time.Millisecond*10, "ping")
+	require.EqualError(t, err, ContextDoneErr.Error())
+	require.Nil(t, res)
+	require.Len(t, r.pending, 0)
+}
+
+func TestRequester_ClosedRequests(t *testing.T) {
+	requests, replies := New(), New()
+	r := NewRequester(requests, replies)
+	defer r.Close()
+
+	requests.CancelAll()
+	_, err := r.Request(context.Background(), "ping")
+	require.EqualError(t, err, ErrChannelClosed.Error())
+	require.Len(t, r.pending, 0)
+}
+
+func TestRequester_Gather(t *testing.T) {
+	requests, replies := New(), New()
+	responders := make([]*Waiter, 0)
+	for i := 0; i < 3; i++ {
+		id := i
+		responders = append(responders, respond(requests, replies, func(payload interface{}) interface{} {
+			return id
+		}))
+	}
+	r := NewRequester(requests, replies)
+
+	t.Run("n replies", func(t *testing.T) {
+		res, err := r.Gather(context.Background(), "ping", 3)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []interface{}{0, 1, 2}, res)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+		res, err := r.Gather(ctx, "ping", 4)
+		require.EqualError(t, err, ContextDoneErr.Error())
+		require.ElementsMatch(t, []interface{}{0, 1, 2}, res)
+	})
+
+	t.Run("fewer than replies", func(t *testing.T) {
+		res, err := r.Gather(context.Background(), "ping", 2)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+	})
+
+	r.Close()
+	for _, w := range responders {
+		w.Close()
+	}
+	goleak.VerifyNone(t)
+}
@@ -0,0 +1,44 @@
+package channel
+
+import (
+	"context"
+	"reflect"
+)
+
+// Select blocks until one of the waiters receives an object and returns the waiter's index and the object.
+// Returns ErrClosed (and the waiter's index) if a waiter is closed with no more objects, ContextDoneErr (and -1) if the context is done
+func Select(ctx context.Context, waiters ...*Waiter) (int, interface{}, error) {
+	return selectUntil(ctx, nil, waiters...)
+}
+
+// selectUntil is like Select but also returns ContextDoneErr (and -1) once stop is closed
+func selectUntil(ctx context.Context, stop <-chan struct{}, waiters ...*Waiter) (int, interface{}, error) {
+	// case 0 is the context, case 1 is stop, followed by a receive case and a done case per waiter
+	cases := make([]reflect.SelectCase, 0, 2+len(waiters)*2)
+	cases = append(cases,
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stop)},
+	)
+	for _, w := range waiters {
+		cases = append(cases,
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.c)},
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.done)},
+		)
+	}
+
+	chosen, value, _ := reflect.Select(cases)
+	if chosen < 2 {
+		return -1, nil, ContextDoneErr
+	}
+
+	idx := (chosen - 2) / 2
+	if (chosen-2)%2 == 0 { // received an object
+		return idx, value.Interface(), nil
+	}
+
+	// waiter closed, deliver objects fired before closing first
+	if obj, ok := waiters[idx].receiveClosed(); ok {
+		return idx, obj, nil
+	}
+	return idx, nil, ErrClosed
+}
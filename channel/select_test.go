@@ -0,0 +1,73 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaiter_C(t *testing.T) {
+	w := NewWaiter()
+	w.Fire("test")
+
+	select {
+	case obj := <-w.C():
+		require.EqualValues(t, "test", obj)
+	case <-time.After(time.Second):
+		require.Fail(t, "nothing received")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	t.Run("first to fire", func(t *testing.T) {
+		waiters := []*Waiter{NewWaiter(), NewWaiter(), NewWaiter()}
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			waiters[1].Fire("second")
+		}()
+
+		idx, obj, err := Select(context.Background(), waiters...)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, idx)
+		require.EqualValues(t, "second", obj)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+
+		idx, obj, err := Select(ctx, NewWaiter(), NewWaiter())
+		require.EqualError(t, err, ContextDoneErr.Error())
+		require.EqualValues(t, -1, idx)
+		require.Nil(t, obj)
+	})
+
+	t.Run("closed waiter", func(t *testing.T) {
+		c := New()
+		waiters := []*Waiter{NewWaiter(), c.Register()}
+		c.FireOnceToAll("last")
+
+		idx, obj, err := Select(context.Background(), waiters...)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, idx)
+		require.EqualValues(t, "last", obj)
+
+		idx, obj, err = Select(context.Background(), waiters...)
+		require.EqualError(t, err, ErrClosed.Error())
+		require.EqualValues(t, 1, idx)
+		require.Nil(t, obj)
+	})
+
+	t.Run("no leaks", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			Select(ctx, NewWaiter(), NewWaiter())
+			cancel()
+		}
+		goleak.VerifyNone(t)
+	})
+}
@@ -0,0 +1,82 @@
+package channel
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds a channel's delivery counters and its registered waiters' stats
+type Stats struct {
+	// Fired is the number of objects fired through the channel
+	Fired uint64
+	// Delivered is the number of objects passed to the buffers (or mailboxes) of the channel's waiters
+	Delivered uint64
+	// Dropped is the number of objects dropped by the overflow policies of the channel's waiters
+	Dropped uint64
+	// Blocked is the total time firing blocked on waiters with full buffers
+	Blocked time.Duration
+	// Waiters holds the stats of the registered waiters
+	Waiters []WaiterStats
+}
+
+// WaiterStats holds a waiter's delivery counters and buffer occupancy
+type WaiterStats struct {
+	ID uint64
+	// Buffered is the number of objects in the waiter's buffer
+	Buffered int
+	// BufferSize is the capacity of the waiter's buffer
+	BufferSize int
+	// Queued is the number of objects in the waiter's mailbox, 0 if it has none
+	Queued int
+	// Delivered is the number of objects passed to the waiter's buffer (or mailbox)
+	Delivered uint64
+	// Dropped is the number of objects dropped by the waiter's overflow policy
+	Dropped uint64
+	// Blocked is the total time firing blocked on the waiter's full buffer
+	Blocked time.Duration
+	Closed  bool
+}
+
+// Backlog returns the number of objects waiting to be received
+func (s WaiterStats) Backlog() int {
+	return s.Buffered + s.Queued
+}
+
+// channelStats holds a channel's counters, accessed atomically
+type channelStats struct {
+	fired     uint64
+	delivered uint64
+	dropped   uint64
+	blocked   int64
+}
+
+// Len returns the number of registered waiters
+func (c *Channel) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.registers)
+}
+
+// Stats returns the channel's delivery counters and the stats of its registered waiters, useful for finding leaked or slow waiters
+func (c *Channel) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	ret := Stats{
+		Fired:     atomic.LoadUint64(&c.stats.fired),
+		Delivered: atomic.LoadUint64(&c.stats.delivered),
+		Dropped:   atomic.LoadUint64(&c.stats.dropped),
+		Blocked:   time.Duration(atomic.LoadInt64(&c.stats.blocked)),
+		Waiters:   make([]WaiterStats, 0, len(c.registers)),
+	}
+	if c.ordered {
+		for _, w := range c.order {
+			ret.Waiters = append(ret.Waiters, w.Stats())
+		}
+		return ret
+	}
+	for _, w := range c.registers {
+		ret.Waiters = append(ret.Waiters, w.Stats())
+	}
+	return ret
+}
@@ -0,0 +1,81 @@
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannel_Stats(t *testing.T) {
+	c := New(WithRegistrationOrder())
+	require.EqualValues(t, 0, c.Len())
+
+	w1 := c.Register(WithBufferSize(1), WithOverflow(OverflowDropNewest))
+	w2 := c.Register(WithBufferSize(3))
+	w3 := c.Register(WithFilter(func(obj interface{}) bool { return false }))
+	require.EqualValues(t, 3, c.Len())
+
+	c.FireToAll(1)
+	c.FireToAll(2)
+
+	stats := c.Stats()
+	require.EqualValues(t, 2, stats.Fired)
+	require.EqualValues(t, 3, stats.Delivered) // 1 to w1, 2 to w2, none to the filtering w3
+	require.EqualValues(t, 1, stats.Dropped)
+	require.Len(t, stats.Waiters, 3)
+
+	require.EqualValues(t, WaiterStats{ID: w1.ID(), Buffered: 1, BufferSize: 1, Delivered: 1, Dropped: 1}, stats.Waiters[0])
+	require.EqualValues(t, WaiterStats{ID: w2.ID(), Buffered: 2, BufferSize: 3, Delivered: 2}, stats.Waiters[1])
+	require.EqualValues(t, WaiterStats{ID: w3.ID(), BufferSize: QueueSize}, stats.Waiters[2])
+	require.EqualValues(t, 2, stats.Waiters[1].Backlog())
+
+	w2.Close()
+	require.EqualValues(t, 2, c.Len())
+	require.Len(t, c.Stats().Waiters, 2)
+	require.True(t, w2.Stats().Closed)
+}
+
+func TestChannel_StatsBlocked(t *testing.T) {
+	c := New()
+	w := c.Register(WithBufferSize(0))
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		w.Receive()
+	}()
+	c.FireToAll(1)
+
+	require.GreaterOrEqual(t, c.Stats().Blocked, time.Millisecond*10)
+	require.EqualValues(t, c.Stats().Blocked, w.Stats().Blocked)
+	require.EqualValues(t, 1, c.Stats().Delivered)
+}
+
+func TestChannel_StatsMailbox(t *testing.T) {
+	c := New(WithAsyncDelivery(2))
+	w := c.Register(WithBufferSize(0))
+	for i := 0; i < 5; i++ {
+		c.FireToAll(i)
+	}
+
+	// all objects are queued, the oldest are dropped once the mailbox is full
+	require.EqualValues(t, 5, w.Stats().Delivered)
+	// the mailbox go routine holds one object while blocking on the unbuffered waiter
+	require.Eventually(t, func() bool {
+		stats := w.Stats()
+		return stats.Dropped+uint64(stats.Backlog()) == 4
+	}, time.Second, time.Millisecond)
+	w.Close()
+}
+
+func TestChannel_StatsReplay(t *testing.T) {
+	c := New(WithReplay(2))
+	c.FireToAll(1)
+	c.FireToAll(2)
+
+	w1 := c.Register()
+	w2 := c.Register()
+	require.EqualValues(t, 2, w1.Stats().Delivered)
+	require.EqualValues(t, 2, w2.Stats().Delivered)
+	require.EqualValues(t, 4, c.Stats().Delivered)
+}
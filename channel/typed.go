@@ -0,0 +1,162 @@
+package channel
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TypedChannel is a type safe Channel, only objects of type T are fired through it
+type TypedChannel[T any] struct {
+	c *Channel
+}
+
+// NewTyped returns a new TypedChannel, panics if the object passed to WithBehavior isn't of type T
+func NewTyped[T any](opts ...Option) *TypedChannel[T] {
+	c := New(opts...)
+	for _, obj := range c.replay {
+		cast[T](obj)
+	}
+	return &TypedChannel[T]{
+		c: c,
+	}
+}
+
+// Register will return a typed waiter, see Channel.Register
+func (c *TypedChannel[T]) Register(opts ...WaiterOption) *TypedWaiter[T] {
+	return &TypedWaiter[T]{w: c.c.Register(opts...)}
+}
+
+// RegisterContext will return a typed waiter which is closed once the context is done, see Channel.RegisterContext
+func (c *TypedChannel[T]) RegisterContext(ctx context.Context, opts ...WaiterOption) *TypedWaiter[T] {
+	return &TypedWaiter[T]{w: c.c.RegisterContext(ctx, opts...)}
+}
+
+// DeRegister removes the waiter from the channel and closes it, see Channel.DeRegister
+func (c *TypedChannel[T]) DeRegister(waiter *TypedWaiter[T]) {
+	c.c.DeRegister(waiter.w)
+}
+
+// DeRegisterAll removes and closes all waiters, see Channel.DeRegisterAll
+func (c *TypedChannel[T]) DeRegisterAll() {
+	c.c.DeRegisterAll()
+}
+
+// FireToAll will fire the object through the waiters, see Channel.FireToAll
+func (c *TypedChannel[T]) FireToAll(obj T) error {
+	return c.c.FireToAll(obj)
+}
+
+// FireOnceToAll will fire the object through the waiters and cancel the channel, see Channel.FireOnceToAll
+func (c *TypedChannel[T]) FireOnceToAll(obj T) error {
+	return c.c.FireOnceToAll(obj)
+}
+
+// CancelAll will close all waiters, see Channel.CancelAll
+func (c *TypedChannel[T]) CancelAll() {
+	c.c.CancelAll()
+}
+
+// IsClosed returns true if the channel was cancelled
+func (c *TypedChannel[T]) IsClosed() bool {
+	return c.c.IsClosed()
+}
+
+// Untyped returns the underlying Channel, objects fired through it directly must be of type T or receiving them panics
+func (c *TypedChannel[T]) Untyped() *Channel {
+	return c.c
+}
+
+// TypedWaiter is a type safe Waiter
+type TypedWaiter[T any] struct {
+	w *Waiter
+}
+
+// NewTypedWaiter returns a new TypedWaiter, see NewWaiter
+func NewTypedWaiter[T any](opts ...WaiterOption) *TypedWaiter[T] {
+	return &TypedWaiter[T]{w: NewWaiter(opts...)}
+}
+
+// Wait will block until a new obj is fired, returns T's zero value if the waiter is closed
+//
+// Deprecated: a closed waiter can't be told apart from a fired zero value, use Receive or WaitContext
+func (w *TypedWaiter[T]) Wait() T {
+	obj, _ := w.Receive()
+	return obj
+}
+
+// Receive will block until a new obj is fired, returns false if the waiter is closed and has no more items
+func (w *TypedWaiter[T]) Receive() (T, bool) {
+	obj, ok := w.w.Receive()
+	return cast[T](obj), ok
+}
+
+// Pending returns the number of fired objects waiting to be received, see Waiter.Pending
+func (w *TypedWaiter[T]) Pending() int {
+	return w.w.Pending()
+}
+
+// TryWait is like Receive but never blocks, see Waiter.TryWait
+func (w *TypedWaiter[T]) TryWait() (T, bool) {
+	obj, ok := w.w.TryWait()
+	return cast[T](obj), ok
+}
+
+// DrainAll returns all objects ready to be received without blocking, see Waiter.DrainAll
+func (w *TypedWaiter[T]) DrainAll() []T {
+	objs := w.w.DrainAll()
+	ret := make([]T, len(objs))
+	for i, obj := range objs {
+		ret[i] = cast[T](obj)
+	}
+	return ret
+}
+
+// WaitContext will return a fired object, ContextDoneErr if the context is done or ErrClosed if the waiter is closed
+func (w *TypedWaiter[T]) WaitContext(ctx context.Context) (T, error) {
+	obj, err := w.w.WaitContext(ctx)
+	return cast[T](obj), err
+}
+
+// WaitTimeout is like WaitContext with a timeout
+func (w *TypedWaiter[T]) WaitTimeout(duration time.Duration) (T, error) {
+	obj, err := w.w.WaitTimeout(duration)
+	return cast[T](obj), err
+}
+
+// Fire will pass obj to the waiter, see Waiter.Fire
+func (w *TypedWaiter[T]) Fire(obj T) bool {
+	return w.w.Fire(obj)
+}
+
+// Close closes the waiter and deregisters it from its channel, see Waiter.Close
+func (w *TypedWaiter[T]) Close() {
+	w.w.Close()
+}
+
+// Done returns a chan which is closed once the waiter is closed
+func (w *TypedWaiter[T]) Done() <-chan struct{} {
+	return w.w.Done()
+}
+
+// Dropped returns the number of objects dropped by the overflow policy
+func (w *TypedWaiter[T]) Dropped() uint64 {
+	return w.w.Dropped()
+}
+
+// Untyped returns the underlying Waiter, can be used with Select
+func (w *TypedWaiter[T]) Untyped() *Waiter {
+	return w.w
+}
+
+// cast returns obj as T, T's zero value if obj is nil.
+// Panics if obj isn't of type T, which means it was fired through an untyped channel or waiter
+func cast[T any](obj interface{}) T {
+	ret, ok := obj.(T)
+	if !ok && obj != nil {
+		panic(errors.Errorf("typed channel: fired object of type %T is not a %v", obj, reflect.TypeOf((*T)(nil)).Elem()))
+	}
+	return ret
+}
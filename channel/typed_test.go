@@ -0,0 +1,122 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type typedTestObj struct {
+	val int
+}
+
+func TestTypedChannel(t *testing.T) {
+	c := NewTyped[*typedTestObj]()
+	w1 := c.Register()
+	w2 := c.Register(WithBufferSize(1))
+
+	require.NoError(t, c.FireToAll(&typedTestObj{val: 1}))
+	obj, ok := w1.Receive()
+	require.True(t, ok)
+	require.EqualValues(t, 1, obj.val)
+	obj, ok = w2.Receive()
+	require.True(t, ok)
+	require.EqualValues(t, 1, obj.val)
+
+	c.DeRegister(w2)
+	require.NoError(t, c.FireOnceToAll(&typedTestObj{val: 2}))
+	require.True(t, c.IsClosed())
+	obj, ok = w1.Receive()
+	require.True(t, ok)
+	require.EqualValues(t, 2, obj.val)
+
+	obj, ok = w1.Receive()
+	require.False(t, ok)
+	require.Nil(t, obj)
+	require.EqualError(t, c.FireToAll(&typedTestObj{}), ErrChannelClosed.Error())
+}
+
+func TestTypedWaiter(t *testing.T) {
+	t.Run("value types", func(t *testing.T) {
+		w := NewTypedWaiter[bool]()
+		w.Fire(true)
+		obj, ok := w.Receive()
+		require.True(t, ok)
+		require.True(t, obj)
+	})
+
+	t.Run("interface types", func(t *testing.T) {
+		w := NewTypedWaiter[error]()
+		w.Fire(nil)
+		w.Fire(ErrClosed)
+		obj, ok := w.Receive()
+		require.True(t, ok)
+		require.NoError(t, obj)
+		obj, ok = w.Receive()
+		require.True(t, ok)
+		require.EqualError(t, obj, ErrClosed.Error())
+	})
+
+	t.Run("with timeout", func(t *testing.T) {
+		w := NewTypedWaiter[int]()
+		obj, err := w.WaitTimeout(time.Millisecond * 10)
+		require.EqualError(t, err, ContextDoneErr.Error())
+		require.EqualValues(t, 0, obj)
+
+		w.Fire(5)
+		obj, err = w.WaitTimeout(time.Millisecond * 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 5, obj)
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		w := NewTypedWaiter[int]()
+		_, ok := w.TryWait()
+		require.False(t, ok)
+
+		w.Fire(1)
+		w.Fire(2)
+		require.EqualValues(t, 2, w.Pending())
+		require.EqualValues(t, []int{1, 2}, w.DrainAll())
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		c := NewTyped[int]()
+		w := c.Register()
+		c.CancelAll()
+		_, err := w.WaitContext(context.Background())
+		require.EqualError(t, err, ErrClosed.Error())
+	})
+
+	t.Run("select", func(t *testing.T) {
+		w1 := NewTypedWaiter[int]()
+		w2 := NewTypedWaiter[string]()
+		w2.Fire("test")
+		idx, obj, err := Select(context.Background(), w1.Untyped(), w2.Untyped())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, idx)
+		require.EqualValues(t, "test", obj)
+	})
+}
+
+func TestTyped_TypeMismatch(t *testing.T) {
+	c := NewTyped[int]()
+	w := c.Register()
+	require.NoError(t, c.Untyped().FireToAll("not an int"))
+	require.PanicsWithError(t, "typed channel: fired object of type string is not a int", func() {
+		w.Receive()
+	})
+
+	// nil is received as the zero value
+	e := NewTypedWaiter[error]()
+	e.Untyped().Fire(nil)
+	obj, ok := e.Receive()
+	require.True(t, ok)
+	require.Nil(t, obj)
+
+	require.Panics(t, func() {
+		NewTyped[int](WithBehavior("initial"))
+	})
+}
@@ -2,54 +2,425 @@ package channel
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
 const QueueSize = 5
 
+// lastWaiterID is the id of the last created waiter, accessed atomically
+var lastWaiterID uint64
+
 var ContextDoneErr = errors.New("WAITER_CONTEXT_DONE")
 
+// ErrClosed is returned by waits on a closed waiter once all objects fired before closing were received
+var ErrClosed = errors.New("waiter is closed")
+
+// OverflowPolicy dictates what Fire does when the waiter's buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks Fire until there is room in the buffer
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the fired object
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest buffered object to make room for the fired object
+	OverflowDropOldest
+	// OverflowBlockTimeout blocks Fire until there is room in the buffer or the block timeout passed, then drops the fired object
+	OverflowBlockTimeout
+)
+
+// WaiterOption configures a waiter
+type WaiterOption func(w *Waiter)
+
+// WithBufferSize sets the number of fired objects the waiter buffers, QueueSize by default
+func WithBufferSize(size int) WaiterOption {
+	return func(w *Waiter) {
+		if size < 0 {
+			size = 0
+		}
+		w.c = make(chan interface{}, size)
+	}
+}
+
+// WithOverflow sets the waiter's overflow policy, OverflowBlock by default
+func WithOverflow(policy OverflowPolicy) WaiterOption {
+	return func(w *Waiter) {
+		w.overflow = policy
+	}
+}
+
+// WithBlockTimeout sets the OverflowBlockTimeout overflow policy with the given timeout
+func WithBlockTimeout(timeout time.Duration) WaiterOption {
+	return func(w *Waiter) {
+		w.overflow = OverflowBlockTimeout
+		w.blockTimeout = timeout
+	}
+}
+
+// WithFilter makes the waiter ignore fired objects for which the filter returns false
+func WithFilter(filter func(obj interface{}) bool) WaiterOption {
+	return func(w *Waiter) {
+		w.filter = filter
+	}
+}
+
 type Waiter struct {
-	id string
-	c  chan interface{}
+	id           uint64
+	c            chan interface{}
+	filter       func(obj interface{}) bool
+	overflow     OverflowPolicy
+	blockTimeout time.Duration
+	dropLock     sync.Mutex // dropLock makes dropping the oldest object and firing a new one atomic
+	dropped      uint64     // accessed atomically
+	delivered    uint64     // accessed atomically
+	blocked      int64      // blocked is the total nanoseconds Fire blocked on a full buffer, accessed atomically
+	done         chan struct{}
+	closeOnce    sync.Once
+	parent       *Channel // parent is the channel the waiter is registered to, nil if created by NewWaiter
+	mailbox      *mailbox // mailbox is set for asynchronous waiters, see WithMailbox
 }
 
-func NewWaiter() *Waiter {
-	return &Waiter{
-		id: uuid.New().String(),
-		c:  make(chan interface{}, QueueSize),
+func NewWaiter(opts ...WaiterOption) *Waiter {
+	w := &Waiter{
+		id:   atomic.AddUint64(&lastWaiterID, 1),
+		c:    make(chan interface{}, QueueSize),
+		done: make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.mailbox != nil {
+		go w.mailbox.forward(w)
+	}
+	return w
+}
+
+// ID returns the waiter's unique id, ids are assigned sequentially starting at 1
+func (w *Waiter) ID() uint64 {
+	return w.id
 }
 
 // Wait will block until a new obj is passed from a queue or from firing.
-// If queue has items, will return immediately after popping firs item
+// If queue has items, will return immediately after popping firs item.
+// Returns ErrClosed if the waiter is closed and has no more items
+//
+// Deprecated: ErrClosed is returned as an object, use Receive or WaitContext
 func (w *Waiter) Wait() interface{} {
-	return <-w.c
+	obj, ok := w.Receive()
+	if !ok {
+		return ErrClosed
+	}
+	return obj
+}
+
+// Receive will block until a new obj is fired, returns false if the waiter is closed and has no more items
+func (w *Waiter) Receive() (interface{}, bool) {
+	select {
+	case obj := <-w.c:
+		return obj, true
+	case <-w.done:
+		return w.receiveClosed()
+	}
+}
+
+// Pending returns the number of fired objects waiting to be received, including objects queued in (or being forwarded from) the waiter's mailbox
+func (w *Waiter) Pending() int {
+	ret := len(w.c)
+	if w.mailbox != nil {
+		ret += w.mailbox.pending()
+	}
+	return ret
+}
+
+// TryWait is like Receive but never blocks, returns false if no object is ready to be received.
+// Objects queued in the waiter's mailbox are ready once forwarded to its buffer
+func (w *Waiter) TryWait() (interface{}, bool) {
+	select {
+	case obj := <-w.c:
+		return obj, true
+	default:
+	}
+	if w.isClosed() {
+		return w.receiveClosed()
+	}
+	return nil, false
+}
+
+// DrainAll returns all pending objects, including the ones queued in the waiter's mailbox, without waiting for new fires.
+// At most the number of objects pending when called are returned, so it returns even if objects are fired continuously.
+// Mailbox objects are received as the mailbox go routine forwards them, so DrainAll shouldn't race other receives of a mailbox waiter
+func (w *Waiter) DrainAll() []interface{} {
+	n := w.Pending()
+	ret := make([]interface{}, 0, n)
+	for len(ret) < n {
+		obj, ok := w.TryWait()
+		if !ok && w.mailbox != nil && w.mailbox.pending() > 0 {
+			obj, ok = w.Receive()
+		}
+		if !ok {
+			break
+		}
+		ret = append(ret, obj)
+	}
+	return ret
+}
+
+// Done returns a chan which is closed once the waiter is closed
+func (w *Waiter) Done() <-chan struct{} {
+	return w.done
+}
+
+// C returns the chan fired objects are received from, for use in a select statement.
+// C is never closed, select on Done as well to know when the waiter is closed
+func (w *Waiter) C() <-chan interface{} {
+	return w.c
+}
+
+// WaitContext will return a fired object, ContextDoneErr if the context is done or ErrClosed if the waiter is closed
+func (w *Waiter) WaitContext(ctx context.Context) (interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ContextDoneErr
+	case obj := <-w.c:
+		return obj, nil
+	case <-w.done:
+		if obj, ok := w.receiveClosed(); ok {
+			return obj, nil
+		}
+		return nil, ErrClosed
+	}
+}
+
+// WaitTimeout is like WaitContext with a timeout
+func (w *Waiter) WaitTimeout(duration time.Duration) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	return w.WaitContext(ctx)
 }
 
 // WaitWithTimeout will return a fired object or an error if deadline exceeded
+//
+// Deprecated: errors are returned as objects, use WaitTimeout
 func (w *Waiter) WaitWithTimeout(duration time.Duration) interface{} {
-	c, _ := context.WithTimeout(context.Background(), duration)
-	return w.WaitWithContext(c)
+	obj, err := w.WaitTimeout(duration)
+	if err != nil {
+		return err
+	}
+	return obj
 }
 
-// WaitWithContext will return a fired object or an error if context is done
+// WaitWithContext will return a fired object or an error if context is done, ErrClosed if the waiter is closed
+//
+// Deprecated: errors are returned as objects, use WaitContext
 func (w *Waiter) WaitWithContext(ctx context.Context) interface{} {
-	var ret interface{}
+	obj, err := w.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	return obj
+}
+
+// Fire will pass obj to the waiter's buffer, if the buffer is full the waiter's overflow policy is applied.
+// Returns false if obj (or an older object) was dropped, objects fired to a closed waiter are ignored.
+// Objects not passing the waiter's filter are ignored as well but are not considered dropped
+func (w *Waiter) Fire(obj interface{}) bool {
+	if w.isClosed() {
+		return false
+	}
+	if w.filter != nil && !w.filter(obj) {
+		return true
+	}
+	if w.mailbox != nil {
+		dropNewest := w.overflow == OverflowDropNewest
+		if !w.mailbox.push(obj, dropNewest) {
+			if !dropNewest { // obj was queued in place of the oldest object
+				w.countDelivered()
+			}
+			w.countDropped()
+			return false
+		}
+		w.countDelivered()
+		return true
+	}
+
+	switch w.overflow {
+	case OverflowDropNewest:
+		select {
+		case w.c <- obj:
+			w.countDelivered()
+			return true
+		default:
+			w.countDropped()
+			return false
+		}
+	case OverflowDropOldest:
+		return w.fireDropOldest(obj)
+	}
+
 	select {
-	case <-ctx.Done():
-		ret = ContextDoneErr
-	case obj := <-w.c:
-		ret = obj
+	case w.c <- obj:
+		w.countDelivered()
+		return true
+	default: // full, block below
+	}
+	start := time.Now()
+	defer func() {
+		w.countBlocked(time.Since(start))
+	}()
+
+	if w.overflow == OverflowBlockTimeout {
+		t := time.NewTimer(w.blockTimeout)
+		defer t.Stop()
+		select {
+		case w.c <- obj:
+			w.countDelivered()
+			return true
+		case <-w.done:
+			return false
+		case <-t.C:
+			w.countDropped()
+			return false
+		}
+	}
+	select {
+	case w.c <- obj:
+		w.countDelivered()
+		return true
+	case <-w.done:
+		return false
 	}
+}
+
+// Dropped returns the number of objects dropped by the overflow policy
+func (w *Waiter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Backpressure returns the waiter's mailbox metrics, queued counts are 0 if the waiter has no mailbox
+func (w *Waiter) Backpressure() Backpressure {
+	ret := Backpressure{Dropped: w.Dropped()}
+	if w.mailbox != nil {
+		ret.Queued, ret.MaxQueued = w.mailbox.stats()
+	}
+	return ret
+}
 
+// Stats returns the waiter's delivery counters and buffer occupancy
+func (w *Waiter) Stats() WaiterStats {
+	ret := WaiterStats{
+		ID:         w.id,
+		Buffered:   len(w.c),
+		BufferSize: cap(w.c),
+		Delivered:  atomic.LoadUint64(&w.delivered),
+		Dropped:    w.Dropped(),
+		Blocked:    time.Duration(atomic.LoadInt64(&w.blocked)),
+		Closed:     w.isClosed(),
+	}
+	if w.mailbox != nil {
+		ret.Queued, _ = w.mailbox.stats()
+	}
 	return ret
 }
 
-// Fire will fire obj through the wait function if that function was called (and waiting), if not it will not fire.
-func (w *Waiter) Fire(obj interface{}) {
-	w.c <- obj
+// Close closes the waiter and deregisters it from the channel it was registered to.
+// Objects fired before closing can still be received
+func (w *Waiter) Close() {
+	w.close()
+	if w.parent != nil {
+		w.parent.DeRegister(w)
+	}
+}
+
+// close closes the waiter, objects fired before closing can still be received
+func (w *Waiter) close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+}
+
+func (w *Waiter) isClosed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// receiveClosed returns a buffered (or queued in the mailbox) object of a closed waiter, false if there are none
+func (w *Waiter) receiveClosed() (interface{}, bool) {
+	if w.mailbox != nil {
+		<-w.mailbox.stopped
+	}
+
+	select {
+	case obj := <-w.c:
+		return obj, true
+	default:
+	}
+	if w.mailbox != nil {
+		return w.mailbox.pop()
+	}
+	return nil, false
+}
+
+// countDelivered counts an object passed to the waiter's buffer or mailbox, for the waiter and the channel it's registered to
+func (w *Waiter) countDelivered() {
+	atomic.AddUint64(&w.delivered, 1)
+	if w.parent != nil {
+		atomic.AddUint64(&w.parent.stats.delivered, 1)
+	}
+}
+
+// countDropped counts an object dropped by the overflow policy, for the waiter and the channel it's registered to
+func (w *Waiter) countDropped() {
+	atomic.AddUint64(&w.dropped, 1)
+	if w.parent != nil {
+		atomic.AddUint64(&w.parent.stats.dropped, 1)
+	}
+}
+
+// countBlocked counts the time Fire blocked on a full buffer, for the waiter and the channel it's registered to
+func (w *Waiter) countBlocked(d time.Duration) {
+	atomic.AddInt64(&w.blocked, int64(d))
+	if w.parent != nil {
+		atomic.AddInt64(&w.parent.stats.blocked, int64(d))
+	}
+}
+
+func (w *Waiter) fireDropOldest(obj interface{}) bool {
+	w.dropLock.Lock()
+	defer w.dropLock.Unlock()
+
+	if cap(w.c) == 0 { // nothing buffered to drop, drop obj instead
+		select {
+		case w.c <- obj:
+			w.countDelivered()
+			return true
+		default:
+			w.countDropped()
+			return false
+		}
+	}
+
+	dropped := false
+	for {
+		select {
+		case w.c <- obj:
+			w.countDelivered()
+			return !dropped
+		default:
+		}
+
+		select {
+		case <-w.c:
+			w.countDropped()
+			dropped = true
+		default: // consumed in between, try again
+		}
+	}
 }
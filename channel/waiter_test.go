@@ -13,6 +13,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// receive returns the next object fired to the waiter, fails if the waiter is closed
+func receive(t *testing.T, w *Waiter) interface{} {
+	obj, ok := w.Receive()
+	require.True(t, ok, "waiter is closed")
+	return obj
+}
+
 func TestWaiterLeaks(t *testing.T) {
 	t.Run("no waiting called", func(t *testing.T) {
 		wg := sync.WaitGroup{}
@@ -38,7 +45,7 @@ func TestWaiterLeaks(t *testing.T) {
 				time.Sleep(time.Millisecond * 25)
 				w.Fire("test")
 			}(w)
-			w.Wait()
+			w.Receive()
 			wg.Done()
 		}
 		wg.Wait()
@@ -63,7 +70,7 @@ func TestWaiterNormal(t *testing.T) {
 
 	res := threadsafe.Bool()
 	go func(res *threadsafe.SafeBool) {
-		newVal := w.Wait()
+		newVal, _ := w.Receive()
 		res.Set(newVal.(bool))
 	}(res)
 
@@ -84,7 +91,7 @@ func TestWaiterQueue(t *testing.T) {
 	cnt := 0
 	for {
 		cnt++
-		if w.Wait() == nil {
+		if obj, _ := w.Receive(); obj == nil {
 			break
 		}
 	}
@@ -133,3 +140,226 @@ func TestWaiterWithContext(t *testing.T) {
 	require.True(t, fired.Get())
 	require.False(t, res.Get())
 }
+
+func TestWaiterOverflow(t *testing.T) {
+	t.Run("buffer size", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(10), WithOverflow(OverflowDropNewest))
+		for i := 0; i < 10; i++ {
+			require.True(t, w.Fire(i))
+		}
+		require.False(t, w.Fire(10))
+		require.EqualValues(t, 1, w.Dropped())
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(2), WithOverflow(OverflowDropNewest))
+		require.True(t, w.Fire(1))
+		require.True(t, w.Fire(2))
+		require.False(t, w.Fire(3))
+		require.False(t, w.Fire(4))
+		require.EqualValues(t, 2, w.Dropped())
+		require.EqualValues(t, 1, receive(t, w))
+		require.EqualValues(t, 2, receive(t, w))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(2), WithOverflow(OverflowDropOldest))
+		require.True(t, w.Fire(1))
+		require.True(t, w.Fire(2))
+		require.False(t, w.Fire(3))
+		require.False(t, w.Fire(4))
+		require.EqualValues(t, 2, w.Dropped())
+		require.EqualValues(t, 3, receive(t, w))
+		require.EqualValues(t, 4, receive(t, w))
+	})
+
+	t.Run("drop oldest without buffer", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(0), WithOverflow(OverflowDropOldest))
+		require.False(t, w.Fire(1))
+		require.EqualValues(t, 1, w.Dropped())
+	})
+
+	t.Run("block with timeout", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(1), WithBlockTimeout(time.Millisecond*25))
+		require.True(t, w.Fire(1))
+
+		t1 := time.Now()
+		require.False(t, w.Fire(2))
+		require.GreaterOrEqual(t, time.Since(t1).Milliseconds(), int64(25))
+		require.EqualValues(t, 1, w.Dropped())
+
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			w.Receive()
+		}()
+		require.True(t, w.Fire(3))
+		require.EqualValues(t, 3, receive(t, w))
+	})
+}
+
+func TestWaiterClosed(t *testing.T) {
+	w := NewWaiter()
+	require.True(t, w.Fire(1))
+	w.close()
+	require.False(t, w.Fire(2))
+
+	select {
+	case <-w.Done():
+	default:
+		require.Fail(t, "done should be closed")
+	}
+
+	obj, ok := w.Receive()
+	require.True(t, ok)
+	require.EqualValues(t, 1, obj)
+	obj, ok = w.Receive()
+	require.False(t, ok)
+	require.Nil(t, obj)
+	require.EqualValues(t, ErrClosed, w.Wait())
+	require.EqualValues(t, ErrClosed, w.WaitWithTimeout(time.Millisecond*10))
+}
+
+func TestWaiterCloseUnblocksFire(t *testing.T) {
+	w := NewWaiter(WithBufferSize(0))
+	go func() {
+		time.Sleep(time.Millisecond * 25)
+		w.close()
+	}()
+	require.False(t, w.Fire(1))
+}
+
+func TestWaiterDoneInSelect(t *testing.T) {
+	w := NewWaiter()
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		w.close()
+	}()
+
+	select {
+	case <-w.Done():
+	case <-time.After(time.Second):
+		require.Fail(t, "waiter wasn't closed")
+	}
+}
+
+func TestWaiterWaitContext(t *testing.T) {
+	t.Run("fired", func(t *testing.T) {
+		w := NewWaiter()
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			w.Fire(ContextDoneErr) // an error fired as an object isn't returned as an error
+		}()
+		obj, err := w.WaitContext(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, ContextDoneErr, obj)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		w := NewWaiter()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		obj, err := w.WaitContext(ctx)
+		require.EqualError(t, err, ContextDoneErr.Error())
+		require.Nil(t, obj)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		w := NewWaiter()
+		obj, err := w.WaitTimeout(time.Millisecond * 10)
+		require.EqualError(t, err, ContextDoneErr.Error())
+		require.Nil(t, obj)
+
+		w.Fire(1)
+		obj, err = w.WaitTimeout(time.Millisecond * 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, obj)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		w := NewWaiter()
+		w.Fire(1)
+		w.Close()
+		obj, err := w.WaitTimeout(time.Second)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, obj)
+		_, err = w.WaitTimeout(time.Second)
+		require.EqualError(t, err, ErrClosed.Error())
+	})
+}
+
+func TestWaiterID(t *testing.T) {
+	ids := make(chan uint64, 100)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids <- NewWaiter().ID()
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	unique := make(map[uint64]bool)
+	for id := range ids {
+		require.NotZero(t, id)
+		unique[id] = true
+	}
+	require.Len(t, unique, 100)
+}
+
+func TestWaiter_Drain(t *testing.T) {
+	t.Run("buffered", func(t *testing.T) {
+		w := NewWaiter()
+		require.EqualValues(t, 0, w.Pending())
+		obj, ok := w.TryWait()
+		require.False(t, ok)
+		require.Nil(t, obj)
+		require.Len(t, w.DrainAll(), 0)
+
+		for i := 0; i < 3; i++ {
+			w.Fire(i)
+		}
+		require.EqualValues(t, 3, w.Pending())
+		obj, ok = w.TryWait()
+		require.True(t, ok)
+		require.EqualValues(t, 0, obj)
+		require.EqualValues(t, []interface{}{1, 2}, w.DrainAll())
+		require.EqualValues(t, 0, w.Pending())
+	})
+
+	t.Run("mailbox", func(t *testing.T) {
+		w := NewWaiter(WithBufferSize(1), WithMailbox(0))
+		for i := 0; i < 5; i++ {
+			w.Fire(i)
+		}
+		// the object held by the mailbox go routine is pending as well
+		require.EqualValues(t, 5, w.Pending())
+
+		// objects are received in order as they're forwarded from the mailbox
+		require.EqualValues(t, []interface{}{0, 1, 2, 3, 4}, w.DrainAll())
+		require.EqualValues(t, 0, w.Pending())
+		w.Close()
+	})
+
+	t.Run("async channel", func(t *testing.T) {
+		c := New(WithAsyncDelivery(0))
+		w := c.Register()
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.FireToAll(i))
+		}
+		require.EqualValues(t, 100, w.Pending())
+		require.Len(t, w.DrainAll(), 100)
+		c.CancelAll()
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		w := NewWaiter(WithMailbox(0))
+		w.Fire(1)
+		w.Fire(2)
+		w.Close()
+		require.EqualValues(t, []interface{}{1, 2}, w.DrainAll())
+		_, ok := w.TryWait()
+		require.False(t, ok)
+	})
+}
@@ -1,7 +1,10 @@
 package functions
 
 import (
+	"context"
+
 	"github.com/bloxapp/go-threading/channel"
+	"github.com/bloxapp/go-threading/future"
 	"github.com/pkg/errors"
 )
 
@@ -17,7 +20,7 @@ type FuncResult struct {
 type StoppableFunc struct {
 	fn      FuncWithStop
 	Manager FuncManager
-	Result  *channel.Channel
+	Result  *channel.TypedChannel[*FuncResult]
 }
 
 // NewStoppableF will run a provided function in a new go routine with a funcManager and a results channel which returns FuncResult
@@ -25,31 +28,39 @@ func NewStoppableF(fn FuncWithStop) *StoppableFunc {
 	return &StoppableFunc{
 		fn:      fn,
 		Manager: newFuncManager(),
-		Result:  channel.New(),
+		Result:  channel.NewTyped[*FuncResult](),
 	}
 }
 
 // Start will start the function and wait for it to complete or cancel
 func (s *StoppableFunc) Start() *FuncResult {
-	w := s.Result.Register()
+	res, _ := s.StartAsync().Get(context.Background())
+	return res
+}
+
+// StartAsync will start the function and return a future resolved with its result once it completes or cancels.
+// The result is fired through Result as well
+func (s *StoppableFunc) StartAsync() *future.Future[*FuncResult] {
+	f := future.New[*FuncResult]()
 	go func() {
+		var res *FuncResult
 		defer func() {
 			if err := recover(); err != nil {
-				s.Result.FireToAll(&FuncResult{
+				res = &FuncResult{
 					Err:       errors.Errorf("panic: %s", err),
 					Completed: false,
-				})
+				}
 			}
+			f.Resolve(res)
+			s.Result.FireToAll(res)
 		}()
 
-		res, err, completed := s.fn(s.Manager)
-		s.Result.FireToAll(&FuncResult{
-			Obj:       res,
+		obj, err, completed := s.fn(s.Manager)
+		res = &FuncResult{
+			Obj:       obj,
 			Err:       err,
 			Completed: completed,
-		})
+		}
 	}()
-
-	res := w.Wait()
-	return res.(*FuncResult)
+	return f
 }
@@ -1,6 +1,7 @@
 package functions
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -93,3 +94,31 @@ func TestStoppedF(t *testing.T) {
 	require.NoError(t, res.Err)
 	require.False(t, res.Completed)
 }
+
+func TestStartAsync(t *testing.T) {
+	sf := NewStoppableF(func(stopper FuncManager) (interface{}, error, bool) {
+		time.Sleep(time.Millisecond * 10)
+		return "done", nil, true
+	})
+	w := sf.Result.Register()
+
+	res, err := sf.StartAsync().Then(func(res *FuncResult) (*FuncResult, error) {
+		return &FuncResult{Obj: res.Obj.(string) + "!", Completed: res.Completed}, nil
+	}).Get(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, "done!", res.Obj)
+	require.True(t, res.Completed)
+
+	// the result is fired through the channel as well
+	fired, ok := w.Receive()
+	require.True(t, ok)
+	require.EqualValues(t, "done", fired.Obj)
+
+	res, err = NewStoppableF(func(stopper FuncManager) (interface{}, error, bool) {
+		panic("oops")
+	}).StartAsync().Get(context.Background())
+	require.NoError(t, err)
+	require.EqualError(t, res.Err, "panic: oops")
+	require.False(t, res.Completed)
+	goleak.VerifyNone(t)
+}
@@ -11,10 +11,13 @@ func NewTimeoutF(fn FuncWithStop, t time.Duration) *FuncResult {
 	sf := NewStoppableF(fn)
 
 	tmr := timer.New()
-	go func(tmr *timer.RoundTimer) {
-		tmr.ResultChan().Wait()
-		sf.Manager.Stop()
-	}(tmr)
+	results := tmr.Results()
+	go func() {
+		defer results.Close()
+		if res, ok := results.Receive(); ok && res.Type == timer.Lapsed {
+			sf.Manager.Stop()
+		}
+	}()
 	tmr.Reset(t)
 
 	res := sf.Start()
@@ -0,0 +1,86 @@
+package future
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// All returns a future resolved with the values of all futures (in order) once all are resolved, or rejected with the first error
+func All[T any](futures ...*Future[T]) *Future[[]T] {
+	ret := New[[]T]()
+	values := make([]T, len(futures))
+	if len(futures) == 0 {
+		ret.Resolve(values)
+		return ret
+	}
+
+	lock := sync.Mutex{}
+	pending := len(futures)
+	for i, f := range futures {
+		i, f := i, f
+		f.onSettle(func() {
+			if f.err != nil {
+				ret.Reject(f.err)
+				return
+			}
+
+			lock.Lock()
+			values[i] = f.value
+			pending--
+			last := pending == 0
+			lock.Unlock()
+			if last {
+				ret.Resolve(values)
+			}
+		})
+	}
+	return ret
+}
+
+// Any returns a future resolved with the value of the first resolved future, or rejected once all futures are rejected
+func Any[T any](futures ...*Future[T]) *Future[T] {
+	ret := New[T]()
+	if len(futures) == 0 {
+		ret.Reject(ErrNoFutures)
+		return ret
+	}
+
+	lock := sync.Mutex{}
+	pending := len(futures)
+	for _, f := range futures {
+		f := f
+		f.onSettle(func() {
+			if f.err == nil {
+				ret.Resolve(f.value)
+				return
+			}
+
+			lock.Lock()
+			pending--
+			last := pending == 0
+			lock.Unlock()
+			if last {
+				ret.Reject(errors.Wrap(f.err, "all futures rejected"))
+			}
+		})
+	}
+	return ret
+}
+
+// Race returns a future settled like the first settled future, either resolved or rejected
+func Race[T any](futures ...*Future[T]) *Future[T] {
+	ret := New[T]()
+	if len(futures) == 0 {
+		ret.Reject(ErrNoFutures)
+		return ret
+	}
+
+	for _, f := range futures {
+		f := f
+		f.onSettle(func() {
+			ret.settle(f.value, f.err)
+		})
+	}
+	return ret
+}
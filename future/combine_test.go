@@ -0,0 +1,75 @@
+package future
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(t *testing.T) {
+	t.Run("resolved in order", func(t *testing.T) {
+		f1, f2, f3 := New[int](), New[int](), New[int]()
+		all := All(f1, f2, f3)
+		f3.Resolve(3)
+		f1.Resolve(1)
+		f2.Resolve(2)
+
+		v, err := all.Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, []int{1, 2, 3}, v)
+	})
+
+	t.Run("first rejection", func(t *testing.T) {
+		f1, f2 := New[int](), New[int]()
+		all := All(f1, f2)
+		f2.Reject(errors.New("err"))
+
+		_, err := all.Get(context.Background())
+		require.EqualError(t, err, "err")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		v, err := All[int]().Get(context.Background())
+		require.NoError(t, err)
+		require.Len(t, v, 0)
+	})
+}
+
+func TestAny(t *testing.T) {
+	t.Run("first resolved", func(t *testing.T) {
+		f1, f2 := New[int](), New[int]()
+		first := Any(f1, f2)
+		f1.Reject(errors.New("err"))
+		f2.Resolve(2)
+
+		v, err := first.Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, 2, v)
+	})
+
+	t.Run("all rejected", func(t *testing.T) {
+		_, err := Any(Rejected[int](errors.New("err1")), Rejected[int](errors.New("err2"))).Get(context.Background())
+		require.EqualError(t, err, "all futures rejected: err2")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := Any[int]().Get(context.Background())
+		require.EqualError(t, err, ErrNoFutures.Error())
+	})
+}
+
+func TestRace(t *testing.T) {
+	f1, f2 := New[int](), New[int]()
+	race := Race(f1, f2)
+	f2.Reject(errors.New("err"))
+	f1.Resolve(1)
+
+	_, err := race.Get(context.Background())
+	require.EqualError(t, err, "err")
+
+	_, err = Race[int]().Get(context.Background())
+	require.EqualError(t, err, ErrNoFutures.Error())
+}
@@ -0,0 +1,155 @@
+package future
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoFutures is returned by Any and Race when called without futures
+var ErrNoFutures = errors.New("no futures")
+
+// Future holds a value (or an error) which is set exactly once, it's safe for many readers
+type Future[T any] struct {
+	lock      sync.Mutex
+	settled   bool
+	value     T
+	err       error
+	done      chan struct{}
+	callbacks []func()
+}
+
+// New returns an unsettled Future, settle it with Resolve or Reject
+func New[T any]() *Future[T] {
+	return &Future[T]{
+		lock:      sync.Mutex{},
+		done:      make(chan struct{}),
+		callbacks: make([]func(), 0),
+	}
+}
+
+// Resolved returns a Future resolved with value
+func Resolved[T any](value T) *Future[T] {
+	f := New[T]()
+	f.Resolve(value)
+	return f
+}
+
+// Rejected returns a Future rejected with err
+func Rejected[T any](err error) *Future[T] {
+	f := New[T]()
+	f.Reject(err)
+	return f
+}
+
+// Go runs fn in a new go routine and returns a Future settled with its result, a panic rejects the future
+func Go[T any](fn func() (T, error)) *Future[T] {
+	f := New[T]()
+	go func() {
+		f.settle(call(fn))
+	}()
+	return f
+}
+
+// Resolve sets the future's value, returns false if the future was already settled
+func (f *Future[T]) Resolve(value T) bool {
+	return f.settle(value, nil)
+}
+
+// Reject sets the future's error, returns false if the future was already settled
+func (f *Future[T]) Reject(err error) bool {
+	var zero T
+	return f.settle(zero, err)
+}
+
+// Get blocks until the future is settled and returns its value and error, or the context's error if done first
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case <-f.done:
+		return f.value, f.err
+	}
+}
+
+// Done returns a chan which is closed once the future is settled
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Then returns a future settled with fn's result once f is resolved, if f is rejected the returned future is rejected with the same error.
+// Callbacks run on the go routine settling f
+func (f *Future[T]) Then(fn func(value T) (T, error)) *Future[T] {
+	next := New[T]()
+	f.onSettle(func() {
+		if f.err != nil {
+			next.Reject(f.err)
+			return
+		}
+		next.settle(call(func() (T, error) {
+			return fn(f.value)
+		}))
+	})
+	return next
+}
+
+// Catch returns a future settled with fn's result if f is rejected, if f is resolved the returned future is resolved with the same value.
+// Callbacks run on the go routine settling f
+func (f *Future[T]) Catch(fn func(err error) (T, error)) *Future[T] {
+	next := New[T]()
+	f.onSettle(func() {
+		if f.err == nil {
+			next.Resolve(f.value)
+			return
+		}
+		next.settle(call(func() (T, error) {
+			return fn(f.err)
+		}))
+	})
+	return next
+}
+
+// settle sets the future's value and error and runs its callbacks, returns false if already settled
+func (f *Future[T]) settle(value T, err error) bool {
+	f.lock.Lock()
+	if f.settled {
+		f.lock.Unlock()
+		return false
+	}
+	f.settled = true
+	f.value = value
+	f.err = err
+	close(f.done)
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.lock.Unlock()
+
+	for _, cb := range callbacks {
+		cb()
+	}
+	return true
+}
+
+// onSettle runs cb once the future is settled, immediately if it already is
+func (f *Future[T]) onSettle(cb func()) {
+	f.lock.Lock()
+	if !f.settled {
+		f.callbacks = append(f.callbacks, cb)
+		f.lock.Unlock()
+		return
+	}
+	f.lock.Unlock()
+	cb()
+}
+
+// call runs fn and turns a panic into an error
+func call[T any](fn func() (T, error)) (ret T, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("panic: %s", e)
+		}
+	}()
+	return fn()
+}
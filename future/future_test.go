@@ -0,0 +1,114 @@
+package future
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuture_SetOnce(t *testing.T) {
+	f := New[int]()
+	require.True(t, f.Resolve(1))
+	require.False(t, f.Resolve(2))
+	require.False(t, f.Reject(errors.New("err")))
+
+	v, err := f.Get(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, v)
+	<-f.Done()
+}
+
+func TestFuture_ManyReaders(t *testing.T) {
+	f := New[string]()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := f.Get(context.Background())
+			require.NoError(t, err)
+			require.EqualValues(t, "value", v)
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	f.Resolve("value")
+	wg.Wait()
+}
+
+func TestFuture_Context(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err := New[int]().Get(ctx)
+	require.EqualError(t, err, context.DeadlineExceeded.Error())
+}
+
+func TestGo(t *testing.T) {
+	v, err := Go(func() (int, error) {
+		return 1, nil
+	}).Get(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, v)
+
+	_, err = Go(func() (int, error) {
+		panic("oops")
+	}).Get(context.Background())
+	require.EqualError(t, err, "panic: oops")
+	goleak.VerifyNone(t)
+}
+
+func TestThenCatch(t *testing.T) {
+	double := func(v int) (int, error) { return v * 2, nil }
+
+	t.Run("then", func(t *testing.T) {
+		f := New[int]()
+		next := f.Then(double).Then(double)
+		f.Resolve(1)
+		v, err := next.Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, 4, v)
+
+		// chaining a settled future
+		v, err = f.Then(double).Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, 2, v)
+	})
+
+	t.Run("rejection skips then", func(t *testing.T) {
+		called := false
+		v, err := Rejected[int](errors.New("err")).Then(func(v int) (int, error) {
+			called = true
+			return v, nil
+		}).Catch(func(err error) (int, error) {
+			return -1, nil
+		}).Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, -1, v)
+		require.False(t, called)
+	})
+
+	t.Run("catch passes values", func(t *testing.T) {
+		v, err := Resolved(1).Catch(func(err error) (int, error) {
+			return -1, nil
+		}).Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, v)
+	})
+
+	t.Run("then error and panic", func(t *testing.T) {
+		_, err := Resolved(1).Then(func(v int) (int, error) {
+			return 0, errors.New("then failed")
+		}).Get(context.Background())
+		require.EqualError(t, err, "then failed")
+
+		_, err = Resolved(1).Then(func(v int) (int, error) {
+			panic("oops")
+		}).Get(context.Background())
+		require.EqualError(t, err, "panic: oops")
+	})
+}
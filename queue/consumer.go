@@ -0,0 +1,214 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bloxapp/go-threading/functions"
+)
+
+const (
+	// ConsumerPollInterval is the default time a worker sleeps when the index is empty
+	ConsumerPollInterval = time.Millisecond * 10
+)
+
+// Handler processes a single popped item, a returned error (or panic) will cause a retry if configured
+type Handler func(obj interface{}) error
+
+// WorkerStats holds the counters of a single consumer worker
+type WorkerStats struct {
+	// Processed is the number of items handled successfully
+	Processed uint64
+	// Failed is the number of items which failed after all retries
+	Failed uint64
+	// Retries is the number of handler retries
+	Retries uint64
+	// Panics is the number of handler calls which panicked
+	Panics uint64
+}
+
+// ConsumerOption configures a Consumer
+type ConsumerOption func(c *Consumer)
+
+// WithRetries will retry a failed item up to maxRetries times, the backoff doubles on every retry up to maxBackoff
+func WithRetries(maxRetries int, backoff, maxBackoff time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithPollInterval sets the time a worker sleeps when the index is empty
+func WithPollInterval(d time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		c.pollInterval = d
+	}
+}
+
+// WithErrorHandler sets a function that's called with items which failed after all retries
+func WithErrorHandler(f func(obj interface{}, err error)) ConsumerOption {
+	return func(c *Consumer) {
+		c.onError = f
+	}
+}
+
+// Consumer is a pool of workers popping items from a queue index and handling them
+type Consumer struct {
+	q       Queue
+	index   Index
+	handler Handler
+
+	maxRetries   int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	onError      func(obj interface{}, err error)
+
+	ctx      context.Context
+	stopOnce sync.Once
+	stopC    chan struct{}
+	wg       sync.WaitGroup
+	stats    []WorkerStats // accessed atomically
+}
+
+// Consume starts workers which pop items from the index and pass them to the handler until ctx is done or Stop is called.
+// Handler panics are recovered and treated as errors.
+func Consume(ctx context.Context, q Queue, index Index, workers int, handler Handler, opts ...ConsumerOption) *Consumer {
+	if workers < 1 {
+		workers = 1
+	}
+
+	c := &Consumer{
+		q:            q,
+		index:        index,
+		handler:      handler,
+		pollInterval: ConsumerPollInterval,
+		ctx:          ctx,
+		stopC:        make(chan struct{}),
+		stats:        make([]WorkerStats, workers),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	for i := 0; i < workers; i++ {
+		c.wg.Add(1)
+		go c.work(&c.stats[i])
+	}
+	return c
+}
+
+// Stop stops popping new items and blocks until all in flight items are handled
+func (c *Consumer) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopC)
+	})
+	c.wg.Wait()
+}
+
+// Stats returns a snapshot of every worker's stats
+func (c *Consumer) Stats() []WorkerStats {
+	ret := make([]WorkerStats, len(c.stats))
+	for i := range c.stats {
+		ret[i] = WorkerStats{
+			Processed: atomic.LoadUint64(&c.stats[i].Processed),
+			Failed:    atomic.LoadUint64(&c.stats[i].Failed),
+			Retries:   atomic.LoadUint64(&c.stats[i].Retries),
+			Panics:    atomic.LoadUint64(&c.stats[i].Panics),
+		}
+	}
+	return ret
+}
+
+func (c *Consumer) work(stats *WorkerStats) {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-c.stopC:
+			return
+		default:
+		}
+
+		obj := c.q.Pop(c.index)
+		if obj == nil {
+			if !c.sleep(c.pollInterval) {
+				return
+			}
+			continue
+		}
+		c.handle(obj, stats)
+	}
+}
+
+// handle calls the handler for the item, retrying with backoff on errors
+func (c *Consumer) handle(obj interface{}, stats *WorkerStats) {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.call(obj, stats)
+		if err == nil {
+			atomic.AddUint64(&stats.Processed, 1)
+			return
+		}
+
+		// in flight items are retried after Stop, but not after the context is done
+		if attempt >= c.maxRetries || !c.sleepContext(backoff) {
+			atomic.AddUint64(&stats.Failed, 1)
+			if c.onError != nil {
+				c.onError(obj, err)
+			}
+			return
+		}
+		atomic.AddUint64(&stats.Retries, 1)
+
+		backoff *= 2
+		if c.maxBackoff > 0 && backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+// call runs the handler as a stoppable function which recovers panics
+func (c *Consumer) call(obj interface{}, stats *WorkerStats) error {
+	res := functions.NewStoppableF(func(stopper functions.FuncManager) (interface{}, error, bool) {
+		return nil, c.handler(obj), true
+	}).Start()
+
+	if !res.Completed {
+		atomic.AddUint64(&stats.Panics, 1)
+	}
+	return res.Err
+}
+
+// sleep returns false if the consumer was stopped or its context is done while sleeping
+func (c *Consumer) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-c.stopC:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// sleepContext returns false if the consumer's context is done while sleeping
+func (c *Consumer) sleepContext(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
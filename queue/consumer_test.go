@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/goleak"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsume(t *testing.T) {
+	q := New(FIFO, 100)
+	for i := 0; i < 50; i++ {
+		q.Add(i, "index")
+	}
+
+	var sum int64
+	c := Consume(context.Background(), q, "index", 4, func(obj interface{}) error {
+		atomic.AddInt64(&sum, int64(obj.(int)))
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		return q.Len() == 0
+	}, time.Second, time.Millisecond*10)
+	c.Stop()
+
+	require.EqualValues(t, 49*50/2, sum)
+	stats := c.Stats()
+	require.Len(t, stats, 4)
+	processed := uint64(0)
+	for _, s := range stats {
+		processed += s.Processed
+	}
+	require.EqualValues(t, 50, processed)
+}
+
+func TestConsumeBoundedConcurrency(t *testing.T) {
+	q := New(FIFO, 100)
+	for i := 0; i < 20; i++ {
+		q.Add(i, "")
+	}
+
+	var running, maxRunning int64
+	c := Consume(context.Background(), q, "", 3, func(obj interface{}) error {
+		cur := atomic.AddInt64(&running, 1)
+		for {
+			prev := atomic.LoadInt64(&maxRunning)
+			if cur <= prev || atomic.CompareAndSwapInt64(&maxRunning, prev, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 5)
+		atomic.AddInt64(&running, -1)
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		return q.Len() == 0
+	}, time.Second, time.Millisecond*10)
+	c.Stop()
+	require.EqualValues(t, 3, maxRunning)
+}
+
+func TestConsumeRetries(t *testing.T) {
+	q := New(FIFO, 10)
+	q.Add("flaky", "")
+	q.Add("broken", "")
+
+	lock := sync.Mutex{}
+	attempts := make(map[string]int)
+	failed := make([]interface{}, 0)
+	c := Consume(context.Background(), q, "", 1, func(obj interface{}) error {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts[obj.(string)]++
+		if obj == "flaky" && attempts["flaky"] < 3 {
+			panic("flaky")
+		}
+		if obj == "broken" {
+			return errors.New("broken")
+		}
+		return nil
+	}, WithRetries(3, time.Millisecond, time.Millisecond*2), WithErrorHandler(func(obj interface{}, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		failed = append(failed, obj)
+		require.EqualError(t, err, "broken")
+	}))
+
+	require.Eventually(t, func() bool {
+		return c.Stats()[0].Processed+c.Stats()[0].Failed == 2
+	}, time.Second, time.Millisecond*10)
+	c.Stop()
+
+	stats := c.Stats()[0]
+	require.EqualValues(t, 1, stats.Processed)
+	require.EqualValues(t, 1, stats.Failed)
+	require.EqualValues(t, 2, stats.Panics)
+	require.EqualValues(t, 5, stats.Retries)
+	require.EqualValues(t, 3, attempts["flaky"])
+	require.EqualValues(t, 4, attempts["broken"])
+	require.EqualValues(t, []interface{}{"broken"}, failed)
+}
+
+func TestConsumeGracefulStop(t *testing.T) {
+	q := New(FIFO, 10)
+	q.Add(1, "")
+	q.Add(2, "")
+
+	started := make(chan struct{})
+	finished := int32(0)
+	c := Consume(context.Background(), q, "", 1, func(obj interface{}) error {
+		close(started)
+		time.Sleep(time.Millisecond * 50)
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+
+	<-started
+	c.Stop()
+	require.EqualValues(t, 1, atomic.LoadInt32(&finished))
+	require.EqualValues(t, 1, q.Len())
+}
+
+func TestConsumeLeaks(t *testing.T) {
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c := Consume(ctx, New(FIFO, 10), "", 8, func(obj interface{}) error {
+			return nil
+		})
+		cancel()
+		c.Stop()
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		q := New(FIFO, 10)
+		q.Add(true, "")
+		c := Consume(context.Background(), q, "", 8, func(obj interface{}) error {
+			return nil
+		})
+		c.Stop()
+		goleak.VerifyNone(t)
+	})
+}
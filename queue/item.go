@@ -1,7 +1,10 @@
 package queue
 
 import (
+	"sync/atomic"
+
 	"github.com/bloxapp/go-threading/channel"
+	"github.com/bloxapp/go-threading/future"
 	"github.com/bloxapp/go-threading/queue/policies"
 )
 
@@ -10,32 +13,50 @@ type ItemState int
 const (
 	ItemPopped    ItemState = 1
 	ItemCancelled ItemState = 2
+	// ItemEvicted is set for items removed by their policies
+	ItemEvicted ItemState = 3
 )
 
 type Item interface {
 	statefullItem
 	PolicyManager() policies.PolicyManager
 	Item() interface{}
-	// Waiter will fire if the item was popped or cancelled
+	// Index returns the index the item was added to
+	Index() Index
+	// Waiter will fire if the item was popped, cancelled or evicted
 	Waiter() *channel.Waiter
+	// Future will be resolved with the item's state once it was popped, cancelled or evicted
+	Future() *future.Future[ItemState]
 }
 
 type statefullItem interface {
 	Popped()
 	Cancelled()
+	Evicted()
 }
 
 type item struct {
+	state   int32 // state is used by lock free queues to claim an item exactly once, accessed atomically
+	owner   *Ring // owner is the ring the item was added to, nil otherwise
 	item    interface{}
+	index   Index
 	waiter  *channel.Waiter
+	future  *future.Future[ItemState]
 	manager policies.PolicyManager
 }
 
 func NewItem(i interface{}, policyManager policies.PolicyManager) Item {
+	return newItem(i, DefaultItemIndex, policyManager)
+}
+
+// newItem returns a new item of the given index
+func newItem(i interface{}, index Index, policyManager policies.PolicyManager) *item {
 	return &item{
 		item:    i,
+		index:   index,
 		manager: policyManager,
 		waiter:  channel.NewWaiter(),
+		future:  future.New[ItemState](),
 	}
 }
 
@@ -47,14 +68,42 @@ func (i *item) Item() interface{} {
 	return i.item
 }
 
+func (i *item) Index() Index {
+	return i.index
+}
+
 func (i *item) Waiter() *channel.Waiter {
 	return i.waiter
 }
 
+func (i *item) Future() *future.Future[ItemState] {
+	return i.future
+}
+
 func (i *item) Popped() {
+	i.future.Resolve(ItemPopped)
 	i.waiter.Fire(ItemPopped)
 }
 
 func (i *item) Cancelled() {
+	i.future.Resolve(ItemCancelled)
 	i.waiter.Fire(ItemCancelled)
 }
+
+func (i *item) Evicted() {
+	i.future.Resolve(ItemEvicted)
+	i.waiter.Fire(ItemEvicted)
+}
+
+// evicted calls Evicted on the items.
+// Like Popped and Cancelled it should be called once the queue is unlocked, so waiters and future callbacks can use the queue
+func evicted(items []Item) {
+	for _, i := range items {
+		i.Evicted()
+	}
+}
+
+// claim will set the item's state if it wasn't set before, returns true if succeeded
+func (i *item) claim(state ItemState) bool {
+	return atomic.CompareAndSwapInt32(&i.state, 0, int32(state))
+}
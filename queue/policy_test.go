@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -23,3 +24,22 @@ func TestTimoutPolicy(t *testing.T) {
 	require.Nil(t, q.Pop(DefaultItemIndex))
 	require.Nil(t, q.Pop(DefaultItemIndex))
 }
+
+func TestTimoutPolicy_Evicted(t *testing.T) {
+	for name, q := range map[string]Queue{
+		"queue":   New(FIFO, 10, policies.TimeOutPolicy(time.Millisecond*25)),
+		"sharded": NewSharded(FIFO, 10, policies.TimeOutPolicy(time.Millisecond*25)),
+		"ring":    NewRing(10, policies.TimeOutPolicy(time.Millisecond*25)),
+	} {
+		_, i := q.AddStateful("test", "")
+		time.Sleep(time.Millisecond * 50)
+		require.Nil(t, q.Pop(DefaultItemIndex), name)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		state, err := i.Future().Get(ctx)
+		cancel()
+		require.NoError(t, err, name)
+		require.EqualValues(t, ItemEvicted, state, name)
+		require.EqualValues(t, ItemEvicted, receive(t, i.Waiter()), name)
+	}
+}
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -20,14 +21,20 @@ const (
 type Queue interface {
 	// Add will add an item to the queue. If no index is provided a default index will be used
 	Add(interface{}, Index) bool
-	// AddStateful is like Add but returns a waiter which will fire when the item is popped or cancelled
-	AddStateful(e interface{}, indexes Index) (bool, *channel.Waiter)
+	// AddStateful is like Add but returns the added item (nil if not added), its waiter fires once it's popped, cancelled or evicted
+	AddStateful(e interface{}, indexes Index) (bool, Item)
 	// Pop will return the next item or nil. If no index provided, the default index will be used
 	Pop(Index) interface{}
 	// PopWait returns a waiter which can be used to pop or wait for a new object and then pop. If no index provided, the default index will be used.
 	PopWait(Index) *channel.Waiter
+	// PopWaitContext is like PopWait but the waiter is closed once the context is done, an item popped meanwhile is handed back to its position in the index
+	PopWaitContext(ctx context.Context, index Index) *channel.Waiter
 	// CancelAndClose will cancel all existing items and delete them for a given index
 	CancelAndClose(index Index)
+	// Cancel will cancel a single item (returned by AddStateful) and delete it, returns false if the item is no longer in the queue
+	Cancel(item Item) bool
+	// CancelWhere will cancel and delete all items of a given index for which the predicate returns true, returns the number of cancelled items
+	CancelWhere(index Index, predicate func(interface{}) bool) int
 	// Len will return the number of items in the queue
 	Len() int
 }
@@ -75,26 +82,49 @@ func (q *queue) Add(e interface{}, index Index) bool {
 	return res
 }
 
-func (q *queue) AddStateful(e interface{}, index Index) (bool, *channel.Waiter) {
-	res, i := q.add(e, index)
-	return res, i.Waiter()
+func (q *queue) AddStateful(e interface{}, index Index) (bool, Item) {
+	return q.add(e, index)
 }
 
 // Pop will return and delete an item from the funcQueue, thread safe.
 func (q *queue) Pop(index Index) interface{} {
+	ret := q.popItem(index)
+	if ret == nil {
+		return nil
+	}
+
+	// fire popped
+	ret.Popped()
+
+	return ret.Item()
+}
+
+// popItem deletes and returns the next item of the index, nil if none. Popped isn't called on the item, see evicted
+func (q *queue) popItem(index Index) Item {
+	ret, evictedItems := q.pop(index)
+
+	// call evicted
+	evicted(evictedItems)
+
+	return ret
+}
+
+// pop is like popItem but returns the items evicted before popping as well
+func (q *queue) pop(index Index) (Item, []Item) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	if q.stop {
-		return nil
+		return nil, nil
 	}
 
 	if len(index) == 0 {
 		index = DefaultItemIndex
 	}
 
-	if q.evictItems() == 0 || len(q.queue[index]) == 0 {
-		return nil
+	count, evictedItems := q.evictItems()
+	if count == 0 || len(q.queue[index]) == 0 {
+		return nil, evictedItems
 	}
 
 	indexedQ := q.queue[index]
@@ -115,49 +145,131 @@ func (q *queue) Pop(index Index) interface{} {
 	if len(q.queue[index]) == 0 {
 		delete(q.queue, index)
 	}
+	return ret, evictedItems
+}
 
-	// fire popped
-	ret.Popped()
+// handBack re-inserts a popped item where it was popped from, it's added even if the queue is at capacity
+func (q *queue) handBack(item Item) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
 
-	return ret.Item()
+	index := item.Index()
+	if q.direction == FIFO {
+		q.queue[index] = append([]Item{item}, q.queue[index]...)
+	} else { // LIFO
+		q.queue[index] = append(q.queue[index], item)
+	}
+	q.count++
 }
 
 func (q *queue) PopWait(index Index) *channel.Waiter {
-	c := channel.New()
-	go func() {
-	loop:
-		for {
-			if obj := q.Pop(index); obj != nil {
-				c.FireToAll(obj)
-				break loop
-			}
-			time.Sleep(PopWaitSleepTime)
-		}
-	}()
-	return c.Register()
+	return popWait(context.Background(), q, index)
+}
+
+func (q *queue) PopWaitContext(ctx context.Context, index Index) *channel.Waiter {
+	return popWait(ctx, q, index)
 }
 
 func (q *queue) CancelAndClose(index Index) {
 	q.lock.Lock()
-	defer q.lock.Unlock()
 
 	if len(index) == 0 {
 		index = DefaultItemIndex
 	}
 
 	if len(q.queue[index]) == 0 {
+		q.lock.Unlock()
 		return
 	}
 
-	// call cancelled on item and add cancelled policy
+	// add cancelled policy
 	indexedQ := q.queue[index]
 	for _, i := range indexedQ {
-		i.Cancelled()
 		i.PolicyManager().AddPolicy(policies.NewCancelledPolicy())
 	}
 
 	// evict
-	q.evictItems()
+	_, evictedItems := q.evictItems()
+	q.lock.Unlock()
+
+	// call cancelled on items once unlocked, items of other indexes were evicted by their own policies
+	for _, i := range indexedQ {
+		i.Cancelled()
+	}
+	for _, i := range evictedItems {
+		if i.Index() != index {
+			i.Evicted()
+		}
+	}
+}
+
+func (q *queue) Cancel(item Item) bool {
+	if item == nil {
+		return false
+	}
+
+	if !q.remove(item) {
+		return false
+	}
+	item.Cancelled()
+	return true
+}
+
+// remove deletes the item from the queue, returns false if not found
+func (q *queue) remove(item Item) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	index := item.Index()
+	indexedQ := q.queue[index]
+	for idx, i := range indexedQ {
+		if i != item {
+			continue
+		}
+		q.queue[index] = append(indexedQ[:idx:idx], indexedQ[idx+1:]...)
+		q.count--
+
+		// delete index if empty
+		if len(q.queue[index]) == 0 {
+			delete(q.queue, index)
+		}
+		return true
+	}
+	return false
+}
+
+func (q *queue) CancelWhere(index Index, predicate func(interface{}) bool) int {
+	q.lock.Lock()
+
+	if len(index) == 0 {
+		index = DefaultItemIndex
+	}
+
+	indexedQ := q.queue[index]
+	newQ := make([]Item, 0, len(indexedQ))
+	cancelled := make([]Item, 0)
+	for _, i := range indexedQ {
+		if predicate(i.Item()) {
+			cancelled = append(cancelled, i)
+		} else {
+			newQ = append(newQ, i)
+		}
+	}
+	q.count -= len(cancelled)
+
+	// delete index if empty
+	if len(newQ) == 0 {
+		delete(q.queue, index)
+	} else {
+		q.queue[index] = newQ
+	}
+	q.lock.Unlock()
+
+	// call cancelled on items once unlocked
+	for _, i := range cancelled {
+		i.Cancelled()
+	}
+	return len(cancelled)
 }
 
 func (q *queue) Len() int {
@@ -166,22 +278,134 @@ func (q *queue) Len() int {
 	return q.count
 }
 
-// evictItems evicts items according to policy and returns total (after eviction) count
+// evictItems evicts items according to policy and returns total (after eviction) count and the evicted items,
+// Evicted should be called on them once unlocked
 // not thread safe, should be called safely
-func (q *queue) evictItems() int {
+func (q *queue) evictItems() (int, []Item) {
 	newCount := 0
+	evictedItems := make([]Item, 0)
 	for index, indexedQ := range q.queue {
 		newQ := make([]Item, 0)
 		for _, i := range indexedQ {
 			if !i.PolicyManager().Evacuate() {
 				newQ = append(newQ, i)
 				newCount++
+			} else {
+				evictedItems = append(evictedItems, i)
 			}
 		}
 		q.queue[index] = newQ
 	}
 	q.count = newCount
-	return newCount
+	return newCount, evictedItems
+}
+
+// itemPopper is implemented by the queues of this package, it lets popWait settle an item only once it was fired
+type itemPopper interface {
+	// popItem deletes and returns the next item of the index without firing popped, nil if none
+	popItem(index Index) Item
+	// handBack re-inserts an item returned by popItem at its original position
+	handBack(item Item)
+}
+
+// popWait polls the queue until an item is popped for the given index and fires it through the returned waiter.
+// Polling stops once the waiter is closed or the context is done. If that happens after an item was popped but before
+// it was fired, the item is handed back to its original position in the index
+func popWait(ctx context.Context, q Queue, index Index) *channel.Waiter {
+	return popWaitLimited(ctx, q, index, nil)
+}
+
+// popWaitLimited is like popWait but, if limiter returns a token bucket, waits for a pop token before every poll.
+// The token is returned to the bucket if nothing was popped or the item was handed back
+func popWaitLimited(ctx context.Context, q Queue, index Index, limiter func() *TokenBucket) *channel.Waiter {
+	c := channel.New()
+	w := c.RegisterContext(ctx)
+	go func() {
+		for {
+			select {
+			case <-w.Done():
+				return
+			default:
+			}
+
+			var bucket *TokenBucket
+			if limiter != nil {
+				bucket = limiter()
+			}
+			if bucket != nil {
+				if d := bucket.reserve(); d > 0 {
+					if !sleepUntilDone(w, d) {
+						return
+					}
+					continue
+				}
+			}
+
+			popped, fired := popAndFire(q, index, w)
+			if bucket != nil && !fired { // nothing was popped or it was handed back, don't waste the token
+				bucket.refund()
+			}
+			if popped {
+				// close the waiter like FireOnceToAll
+				c.CancelAll()
+				return
+			}
+
+			if !sleepUntilDone(w, PopWaitSleepTime) {
+				return
+			}
+		}
+	}()
+	return w
+}
+
+// popAndFire pops the next item of the index and fires it to the waiter, the item is marked as popped only once fired.
+// If the waiter was closed in between the item is handed back, popped is true if an item was popped either way.
+// Queues implemented outside this package can't hand items back, the object is added back to the end of the index
+// and is dropped if the add fails
+func popAndFire(q Queue, index Index, w *channel.Waiter) (popped bool, fired bool) {
+	select {
+	case <-w.Done():
+		return false, false
+	default:
+	}
+
+	p, ok := q.(itemPopper)
+	if !ok {
+		obj := q.Pop(index)
+		if obj == nil {
+			return false, false
+		}
+		if !w.Fire(obj) {
+			q.Add(obj, index)
+			return true, false
+		}
+		return true, true
+	}
+
+	i := p.popItem(index)
+	if i == nil {
+		return false, false
+	}
+	// fire to the waiter itself to know if it was closed in between
+	if !w.Fire(i.Item()) {
+		p.handBack(i)
+		return true, false
+	}
+	i.Popped()
+	return true, true
+}
+
+// sleepUntilDone sleeps for the duration, returns false if the waiter was closed first
+func sleepUntilDone(w *channel.Waiter, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-w.Done():
+		return false
+	case <-t.C:
+		return true
+	}
 }
 
 // preAddCheck will return true if possible to add item
@@ -189,8 +413,9 @@ func (q *queue) preAddCheck() bool {
 
 	if q.Len()+1 > q.capacity {
 		q.lock.Lock()
-		l := q.evictItems()
+		l, evictedItems := q.evictItems()
 		q.lock.Unlock()
+		evicted(evictedItems)
 		if l+1 > q.capacity {
 			return false
 		}
@@ -221,7 +446,7 @@ func (q *queue) add(e interface{}, index Index) (bool, Item) {
 	}
 
 	// generate item
-	i := NewItem(e, policies.NewPolicyManager(newPolicies))
+	i := newItem(e, index, policies.NewPolicyManager(newPolicies))
 
 	if q.queue[index] == nil {
 		q.queue[index] = make([]Item, 0)
@@ -1,11 +1,13 @@
 package queue
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/bloxapp/go-threading/channel"
 	"github.com/bloxapp/go-threading/queue/policies"
 	"github.com/bloxapp/go-threading/threadsafe"
 
@@ -23,6 +25,13 @@ var evictImmediatelyF = func(i ...interface{}) func() policies.Policy {
 type evictImmediately struct {
 }
 
+// receive returns the next object fired to the waiter, fails if the waiter is closed
+func receive(t *testing.T, w *channel.Waiter) interface{} {
+	obj, ok := w.Receive()
+	require.True(t, ok, "waiter is closed")
+	return obj
+}
+
 func newEvictImmediately() policies.Policy {
 	return &evictImmediately{}
 }
@@ -107,9 +116,100 @@ func TestPopWait(t *testing.T) {
 		q.Add("t", "")
 	}()
 
-	require.True(t, q.PopWait(DefaultItemIndex).Wait().(bool))
-	require.EqualValues(t, 1, q.PopWait(DefaultItemIndex).Wait().(int))
-	require.EqualValues(t, "t", q.PopWait(DefaultItemIndex).Wait().(string))
+	require.True(t, receive(t, q.PopWait(DefaultItemIndex)).(bool))
+	require.EqualValues(t, 1, receive(t, q.PopWait(DefaultItemIndex)).(int))
+	require.EqualValues(t, "t", receive(t, q.PopWait(DefaultItemIndex)).(string))
+}
+
+func TestPopWaitClosed(t *testing.T) {
+	t.Run("waiter closed", func(t *testing.T) {
+		q := New(FIFO, 10)
+		w := q.PopWait("")
+		w.Close()
+		time.Sleep(PopWaitSleepTime * 2) // let the poller notice
+
+		q.Add("x", "")
+		time.Sleep(PopWaitSleepTime * 2)
+		require.EqualValues(t, 1, q.Len())
+		require.EqualValues(t, "x", q.Pop(""))
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		q := NewSharded(FIFO, 10)
+		ctx, cancel := context.WithCancel(context.Background())
+		w := q.PopWaitContext(ctx, "")
+		cancel()
+		_, ok := w.Receive()
+		require.False(t, ok)
+
+		q.Add("x", "")
+		time.Sleep(PopWaitSleepTime * 2)
+		require.EqualValues(t, "x", q.Pop(""))
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("concurrent cancel and pop", func(t *testing.T) {
+		for i := 0; i < 2000; i++ {
+			q := New(FIFO, 10)
+			q.Add(i, "")
+			ctx, cancel := context.WithCancel(context.Background())
+			w := q.PopWaitContext(ctx, "")
+			go cancel()
+
+			// the item is either received or handed back to the queue, never lost
+			if obj, ok := w.Receive(); ok {
+				require.EqualValues(t, i, obj)
+				require.EqualValues(t, 0, q.Len())
+				continue
+			}
+			require.Eventually(t, func() bool {
+				return q.Len() == 1
+			}, time.Second, time.Millisecond)
+			require.EqualValues(t, i, q.Pop(""))
+		}
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("handed back to its position", func(t *testing.T) {
+		for name, q := range map[string]Queue{
+			"queue":   New(FIFO, 2),
+			"sharded": NewSharded(FIFO, 2),
+			"ring":    NewRing(2),
+		} {
+			_, x := q.AddStateful("x", "")
+			require.True(t, q.Add("y", ""), name)
+
+			// the waiter is closed after the item was popped but before it was fired
+			popped := q.(itemPopper).popItem("")
+			require.True(t, popped == x, name)
+			require.True(t, q.Add("z", ""), name)
+			q.(itemPopper).handBack(popped)
+
+			require.EqualValues(t, 3, q.Len(), name)
+			select {
+			case <-x.Future().Done():
+				require.Fail(t, "handed back item was settled", name)
+			default:
+			}
+			require.EqualValues(t, "x", q.Pop(""), name)
+			require.EqualValues(t, "y", q.Pop(""), name)
+			require.EqualValues(t, "z", q.Pop(""), name)
+			state, err := x.Future().Get(context.Background())
+			require.NoError(t, err, name)
+			require.EqualValues(t, ItemPopped, state, name)
+		}
+	})
+
+	t.Run("context popped", func(t *testing.T) {
+		q := NewRing(8)
+		w := q.PopWaitContext(context.Background(), "")
+		q.Add("x", "")
+		obj, err := w.WaitTimeout(time.Second)
+		require.NoError(t, err)
+		require.EqualValues(t, "x", obj)
+		goleak.VerifyNone(t)
+	})
 }
 
 func TestAddWhenFull(t *testing.T) {
@@ -131,12 +231,13 @@ func TestAddWhenFull(t *testing.T) {
 func TestAddStateful(t *testing.T) {
 	t.Run("fired when popped", func(t *testing.T) {
 		q := New(FIFO, 3)
-		res, waiter := q.AddStateful("item", "index")
+		res, item := q.AddStateful("item", "index")
 		require.True(t, res)
 
 		called := threadsafe.Int32(0)
 		go func() {
-			called.Set(int32(waiter.Wait().(ItemState)))
+			state, _ := item.Waiter().Receive()
+			called.Set(int32(state.(ItemState)))
 		}()
 
 		time.Sleep(time.Millisecond * 25)
@@ -148,12 +249,13 @@ func TestAddStateful(t *testing.T) {
 
 	t.Run("fired when cancelled", func(t *testing.T) {
 		q := New(FIFO, 3)
-		res, waiter := q.AddStateful("item", "index")
+		res, item := q.AddStateful("item", "index")
 		require.True(t, res)
 
 		called := threadsafe.Int32(0)
 		go func() {
-			called.Set(int32(waiter.Wait().(ItemState)))
+			state, _ := item.Waiter().Receive()
+			called.Set(int32(state.(ItemState)))
 		}()
 
 		time.Sleep(time.Millisecond * 25)
@@ -162,6 +264,144 @@ func TestAddStateful(t *testing.T) {
 		require.EqualValues(t, ItemCancelled, called.Get())
 		require.EqualValues(t, 0, q.Len())
 	})
+
+	t.Run("future resolved", func(t *testing.T) {
+		q := New(FIFO, 3)
+		_, popped := q.AddStateful("popped", "index")
+		_, cancelled := q.AddStateful("cancelled", "index")
+
+		select {
+		case <-popped.Future().Done():
+			t.Fatal("future resolved before pop")
+		default:
+		}
+
+		q.Pop("index")
+		q.Cancel(cancelled)
+		state, err := popped.Future().Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, ItemPopped, state)
+		state, err = cancelled.Future().Get(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, ItemCancelled, state)
+	})
+}
+
+func TestItemFutureCallbacks(t *testing.T) {
+	impls := []struct {
+		name string
+		newQ func() Queue
+	}{
+		{"mutex", func() Queue { return New(FIFO, 10) }},
+		{"sharded", func() Queue { return NewSharded(FIFO, 10) }},
+		{"ring", func() Queue { return NewRing(8) }},
+	}
+
+	for _, impl := range impls {
+		t.Run(impl.name, func(t *testing.T) {
+			q := impl.newQ()
+			lens := make(chan int, 4)
+			// callbacks run when the item is popped or cancelled and may use the queue
+			onState := func(state ItemState) (ItemState, error) {
+				lens <- q.Len()
+				return state, nil
+			}
+
+			_, popped := q.AddStateful(1, "")
+			_, cancelled := q.AddStateful(2, "")
+			_, cancelledWhere := q.AddStateful(3, "")
+			_, closed := q.AddStateful(4, "")
+			for _, i := range []Item{popped, cancelled, cancelledWhere, closed} {
+				i.Future().Then(onState)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				require.EqualValues(t, 1, q.Pop(""))
+				require.True(t, q.Cancel(cancelled))
+				require.EqualValues(t, 1, q.CancelWhere("", func(obj interface{}) bool {
+					return obj.(int) == 3
+				}))
+				q.CancelAndClose("")
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("queue deadlocked by a future callback")
+			}
+			require.Len(t, lens, 4)
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	t.Run("cancel single item", func(t *testing.T) {
+		q := New(FIFO, 10)
+		_, first := q.AddStateful("first", "index")
+		_, second := q.AddStateful("second", "index")
+		_, third := q.AddStateful("third", "index")
+
+		require.True(t, q.Cancel(second))
+		require.EqualValues(t, ItemCancelled, receive(t, second.Waiter()))
+		require.EqualValues(t, 2, q.Len())
+
+		require.EqualValues(t, "first", q.Pop("index"))
+		require.EqualValues(t, "third", q.Pop("index"))
+		require.EqualValues(t, ItemPopped, receive(t, first.Waiter()))
+		require.EqualValues(t, ItemPopped, receive(t, third.Waiter()))
+		require.Nil(t, q.(*queue).queue["index"])
+	})
+
+	t.Run("cancel popped item", func(t *testing.T) {
+		q := New(FIFO, 10)
+		_, item := q.AddStateful("item", "index")
+		require.EqualValues(t, "item", q.Pop("index"))
+		require.False(t, q.Cancel(item))
+		require.False(t, q.Cancel(nil))
+	})
+
+	t.Run("add failed returns nil item", func(t *testing.T) {
+		q := New(FIFO, 1)
+		res, item := q.AddStateful("item", "index")
+		require.True(t, res)
+		require.NotNil(t, item)
+		res, item = q.AddStateful("item", "index")
+		require.False(t, res)
+		require.Nil(t, item)
+	})
+}
+
+func TestCancelWhere(t *testing.T) {
+	q := New(FIFO, 10)
+	items := make([]Item, 0)
+	for i := 0; i < 6; i++ {
+		_, item := q.AddStateful(i, "index")
+		items = append(items, item)
+	}
+	q.Add(0, "other")
+
+	cnt := q.CancelWhere("index", func(obj interface{}) bool {
+		return obj.(int)%2 == 0
+	})
+	require.EqualValues(t, 3, cnt)
+	require.EqualValues(t, 4, q.Len())
+	for i, item := range items {
+		if i%2 == 0 {
+			require.EqualValues(t, ItemCancelled, receive(t, item.Waiter()))
+		}
+	}
+
+	require.EqualValues(t, 1, q.Pop("index"))
+	require.EqualValues(t, 3, q.Pop("index"))
+	require.EqualValues(t, 5, q.Pop("index"))
+	require.Nil(t, q.Pop("index"))
+	require.EqualValues(t, 0, q.Pop("other"))
+
+	require.EqualValues(t, 0, q.CancelWhere("non_existing_index", func(obj interface{}) bool {
+		return true
+	}))
 }
 
 func TestLeaks(t *testing.T) {
@@ -171,7 +411,7 @@ func TestLeaks(t *testing.T) {
 			wg.Add(1)
 			q := New(FIFO, 3)
 			go func(q Queue) {
-				q.PopWait(DefaultItemIndex).Wait()
+				q.PopWait(DefaultItemIndex).Receive()
 				wg.Done()
 			}(q)
 			go func(q Queue) {
@@ -184,3 +424,12 @@ func TestLeaks(t *testing.T) {
 		goleak.VerifyNone(t)
 	})
 }
+
+func BenchmarkQueue_Add(b *testing.B) {
+	q := New(FIFO, b.N+1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Add(i, DefaultItemIndex)
+	}
+}
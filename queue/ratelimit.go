@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bloxapp/go-threading/channel"
+	"github.com/pkg/errors"
+)
+
+// ErrQueueFull is returned when an item couldn't be added to the queue
+var ErrQueueFull = errors.New("queue is full")
+
+// TokenBucket is a thread safe token bucket rate limiter.
+// It's refilled at a constant rate up to burst tokens, every operation consumes one token.
+type TokenBucket struct {
+	lock   sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full token bucket which allows ratePerSecond operations with bursts of up to burst operations
+func NewTokenBucket(ratePerSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		lock:   sync.Mutex{},
+		rate:   ratePerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow consumes a token if available, returns false otherwise
+func (b *TokenBucket) Allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.refill(time.Now()) < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait blocks until a token is consumed or the context is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		d := b.reserve()
+		if d == 0 {
+			return nil
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// refund returns a consumed token to the bucket
+func (b *TokenBucket) refund() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// reserve consumes a token and returns 0 if available, otherwise returns the time until the next token
+func (b *TokenBucket) reserve() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	tokens := b.refill(time.Now())
+	if tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	if b.rate <= 0 {
+		return PopWaitSleepTime
+	}
+	return time.Duration((1 - tokens) / b.rate * float64(time.Second))
+}
+
+// refill adds the tokens accumulated since the last refill and returns the current amount
+// not thread safe, should be called safely
+func (b *TokenBucket) refill(now time.Time) float64 {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	return b.tokens
+}
+
+// RateLimitedQueue is a Queue with optional per index token buckets for adding and popping items.
+// Pop returns nil and Add returns false when the index's limiter has no tokens, PopWait waits for both an item and a token.
+type RateLimitedQueue interface {
+	Queue
+	// SetPopLimit attaches a limiter to popping items from the index, nil removes it
+	SetPopLimit(index Index, limiter *TokenBucket)
+	// SetAddLimit attaches a limiter to adding items to the index, nil removes it
+	SetAddLimit(index Index, limiter *TokenBucket)
+	// AddWait waits for an add token (or until the context is done) and then adds the item, returns ErrQueueFull if the add failed
+	AddWait(ctx context.Context, e interface{}, index Index) error
+}
+
+// rateLimitedQueue wraps a Queue with token buckets
+type rateLimitedQueue struct {
+	Queue
+	lock        sync.RWMutex
+	popLimiters map[Index]*TokenBucket
+	addLimiters map[Index]*TokenBucket
+}
+
+// NewRateLimited wraps a queue so its indexes could be rate limited
+func NewRateLimited(q Queue) RateLimitedQueue {
+	return &rateLimitedQueue{
+		Queue:       q,
+		lock:        sync.RWMutex{},
+		popLimiters: make(map[Index]*TokenBucket),
+		addLimiters: make(map[Index]*TokenBucket),
+	}
+}
+
+func (q *rateLimitedQueue) SetPopLimit(index Index, limiter *TokenBucket) {
+	q.setLimiter(q.popLimiters, index, limiter)
+}
+
+func (q *rateLimitedQueue) SetAddLimit(index Index, limiter *TokenBucket) {
+	q.setLimiter(q.addLimiters, index, limiter)
+}
+
+func (q *rateLimitedQueue) Add(e interface{}, index Index) bool {
+	res, _ := q.AddStateful(e, index)
+	return res
+}
+
+func (q *rateLimitedQueue) AddStateful(e interface{}, index Index) (bool, Item) {
+	limiter := q.getLimiter(q.addLimiters, index)
+	if limiter != nil && !limiter.Allow() {
+		return false, nil
+	}
+
+	res, i := q.Queue.AddStateful(e, index)
+	if !res && limiter != nil {
+		limiter.refund()
+	}
+	return res, i
+}
+
+func (q *rateLimitedQueue) AddWait(ctx context.Context, e interface{}, index Index) error {
+	limiter := q.getLimiter(q.addLimiters, index)
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !q.Queue.Add(e, index) {
+		if limiter != nil {
+			limiter.refund()
+		}
+		return ErrQueueFull
+	}
+	return nil
+}
+
+func (q *rateLimitedQueue) Pop(index Index) interface{} {
+	limiter := q.getLimiter(q.popLimiters, index)
+	if limiter == nil {
+		return q.Queue.Pop(index)
+	}
+
+	if !limiter.Allow() {
+		return nil
+	}
+	obj := q.Queue.Pop(index)
+	if obj == nil { // nothing was popped, don't waste the token
+		limiter.refund()
+	}
+	return obj
+}
+
+func (q *rateLimitedQueue) PopWait(index Index) *channel.Waiter {
+	return q.PopWaitContext(context.Background(), index)
+}
+
+func (q *rateLimitedQueue) PopWaitContext(ctx context.Context, index Index) *channel.Waiter {
+	return popWaitLimited(ctx, q.Queue, index, func() *TokenBucket {
+		return q.getLimiter(q.popLimiters, index)
+	})
+}
+
+func (q *rateLimitedQueue) setLimiter(limiters map[Index]*TokenBucket, index Index, limiter *TokenBucket) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(index) == 0 {
+		index = DefaultItemIndex
+	}
+	if limiter == nil {
+		delete(limiters, index)
+		return
+	}
+	limiters[index] = limiter
+}
+
+func (q *rateLimitedQueue) getLimiter(limiters map[Index]*TokenBucket, index Index) *TokenBucket {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if len(index) == 0 {
+		index = DefaultItemIndex
+	}
+	return limiters[index]
+}
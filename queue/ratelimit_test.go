@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("burst", func(t *testing.T) {
+		b := NewTokenBucket(1, 3)
+		require.True(t, b.Allow())
+		require.True(t, b.Allow())
+		require.True(t, b.Allow())
+		require.False(t, b.Allow())
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		b := NewTokenBucket(100, 1)
+		require.True(t, b.Allow())
+		require.False(t, b.Allow())
+		time.Sleep(time.Millisecond * 15)
+		require.True(t, b.Allow())
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		b := NewTokenBucket(20, 1)
+		require.NoError(t, b.Wait(context.Background()))
+
+		t1 := time.Now()
+		require.NoError(t, b.Wait(context.Background()))
+		require.GreaterOrEqual(t, time.Since(t1).Milliseconds(), int64(40))
+	})
+
+	t.Run("wait with context", func(t *testing.T) {
+		b := NewTokenBucket(1, 1)
+		require.True(t, b.Allow())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*25)
+		defer cancel()
+		require.EqualError(t, b.Wait(ctx), context.DeadlineExceeded.Error())
+	})
+}
+
+func TestRateLimitedPop(t *testing.T) {
+	q := NewRateLimited(New(FIFO, 10))
+	q.SetPopLimit("index", NewTokenBucket(20, 2))
+	for i := 0; i < 5; i++ {
+		q.Add(i, "index")
+		q.Add(i, "other")
+	}
+
+	require.EqualValues(t, 0, q.Pop("index"))
+	require.EqualValues(t, 1, q.Pop("index"))
+	require.Nil(t, q.Pop("index"))
+	require.EqualValues(t, 0, q.Pop("other"))
+
+	// a pop wait should wait for a token
+	t1 := time.Now()
+	require.EqualValues(t, 2, receive(t, q.PopWait("index")))
+	require.GreaterOrEqual(t, time.Since(t1).Milliseconds(), int64(40))
+
+	q.SetPopLimit("index", nil)
+	require.EqualValues(t, 3, q.Pop("index"))
+	require.EqualValues(t, 4, q.Pop("index"))
+}
+
+func TestRateLimitedPopWaitRate(t *testing.T) {
+	q := NewRateLimited(New(FIFO, 100))
+	q.SetPopLimit("index", NewTokenBucket(100, 1))
+	for i := 0; i < 50; i++ {
+		q.Add(i, "index")
+	}
+
+	// 50 pops at 100/s take about half a second, polling every PopWaitSleepTime would take several
+	t1 := time.Now()
+	for i := 0; i < 50; i++ {
+		require.EqualValues(t, i, receive(t, q.PopWait("index")))
+	}
+	elapsed := time.Since(t1)
+	require.GreaterOrEqual(t, elapsed.Milliseconds(), int64(450))
+	require.Less(t, elapsed.Milliseconds(), int64(1000))
+}
+
+func TestRateLimitedPopRefund(t *testing.T) {
+	q := NewRateLimited(New(FIFO, 10))
+	q.SetPopLimit("", NewTokenBucket(1, 1))
+	require.Nil(t, q.Pop(""))
+	q.Add("item", "")
+	require.EqualValues(t, "item", q.Pop(""))
+}
+
+func TestRateLimitedAddRefund(t *testing.T) {
+	q := NewRateLimited(New(FIFO, 1))
+	q.Add(0, "other")
+	// rate 0 so only refunded tokens remain
+	q.SetAddLimit("index", NewTokenBucket(0, 1))
+
+	require.False(t, q.Add(1, "index"))
+	require.EqualError(t, q.AddWait(context.Background(), 1, "index"), ErrQueueFull.Error())
+
+	q.Pop("other")
+	require.True(t, q.Add(1, "index"))
+	require.False(t, q.Add(2, "index"))
+}
+
+func TestRateLimitedAdd(t *testing.T) {
+	q := NewRateLimited(New(FIFO, 3))
+	q.SetAddLimit("index", NewTokenBucket(20, 1))
+
+	require.True(t, q.Add(1, "index"))
+	require.False(t, q.Add(2, "index"))
+	require.True(t, q.Add(1, "other"))
+
+	t1 := time.Now()
+	require.NoError(t, q.AddWait(context.Background(), 2, "index"))
+	require.GreaterOrEqual(t, time.Since(t1).Milliseconds(), int64(40))
+
+	require.EqualError(t, q.AddWait(context.Background(), 3, "other"), ErrQueueFull.Error())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.EqualError(t, q.AddWait(ctx, 4, "index"), context.DeadlineExceeded.Error())
+}
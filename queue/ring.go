@@ -0,0 +1,374 @@
+package queue
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bloxapp/go-threading/channel"
+	"github.com/bloxapp/go-threading/queue/policies"
+)
+
+const (
+	// ringMaxBackoff is the max sleep time between retries of the blocking ring operations
+	ringMaxBackoff = time.Millisecond
+)
+
+// ringSlot holds a single ring item, seq tells producers and consumers whose turn it is
+type ringSlot struct {
+	seq  uint64
+	item atomic.Value // item always holds a *item
+	_    [40]byte     // padding to avoid false sharing between slots
+}
+
+// Ring is a lock free, bounded, multi producer multi consumer queue (based on Dmitry Vyukov's MPMC queue).
+// It implements Queue for a single index, adds or pops for any other index than DefaultItemIndex are rejected.
+// Cancelled or evicted items are skipped lazily, they keep occupying their slot until popped past or until an add
+// finds the ring full and they're at its head.
+type Ring struct {
+	_        [64]byte
+	tail     uint64 // next slot to add to, accessed atomically
+	_        [56]byte
+	head     uint64 // next slot to pop from, accessed atomically
+	_        [56]byte
+	count    int64 // count of items which are neither popped nor cancelled, accessed atomically
+	mask     uint64
+	slots    []ringSlot
+	policies []policies.ApplyPolicy
+	// front holds the items handed back by PopWait, they are popped before the slots' items.
+	// fronts is len(front) and is accessed atomically so pops only lock when items were handed back
+	frontLock sync.Mutex
+	front     []*item
+	fronts    int64
+}
+
+// NewRing returns a new Ring, capacity is rounded up to the next power of 2
+func NewRing(capacity int, policies ...policies.ApplyPolicy) *Ring {
+	size := uint64(2)
+	for size < uint64(capacity) {
+		size <<= 1
+	}
+
+	r := &Ring{
+		mask:     size - 1,
+		slots:    make([]ringSlot, size),
+		policies: policies,
+	}
+	for i := range r.slots {
+		r.slots[i].seq = uint64(i)
+		r.slots[i].item.Store((*item)(nil))
+	}
+	return r
+}
+
+// Cap returns the number of slots in the ring
+func (r *Ring) Cap() int {
+	return len(r.slots)
+}
+
+// TryAdd adds an item without blocking, returns false if the ring is full
+func (r *Ring) TryAdd(e interface{}) bool {
+	return r.tryAdd(r.newItem(e))
+}
+
+// TryPop pops an item without blocking, returns false if the ring is empty
+func (r *Ring) TryPop() (interface{}, bool) {
+	i := r.pop()
+	if i == nil {
+		return nil, false
+	}
+	return i.Item(), true
+}
+
+// AddContext adds an item, blocking until there is room in the ring or the context is done
+func (r *Ring) AddContext(ctx context.Context, e interface{}) error {
+	i := r.newItem(e)
+	for attempt := 0; ; attempt++ {
+		if r.tryAdd(i) {
+			return nil
+		}
+		if err := r.backoff(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// PopContext pops an item, blocking until one is available or the context is done
+func (r *Ring) PopContext(ctx context.Context) (interface{}, error) {
+	for attempt := 0; ; attempt++ {
+		if i := r.pop(); i != nil {
+			return i.Item(), nil
+		}
+		if err := r.backoff(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Add will add an item to the ring, returns false if full or index isn't the default one
+func (r *Ring) Add(e interface{}, index Index) bool {
+	res, _ := r.AddStateful(e, index)
+	return res
+}
+
+func (r *Ring) AddStateful(e interface{}, index Index) (bool, Item) {
+	if !isDefaultIndex(index) {
+		return false, nil
+	}
+	i := r.newItem(e)
+	if !r.tryAdd(i) {
+		return false, nil
+	}
+	return true, i
+}
+
+// Pop will return the next item or nil if empty or index isn't the default one
+func (r *Ring) Pop(index Index) interface{} {
+	if !isDefaultIndex(index) {
+		return nil
+	}
+	obj, _ := r.TryPop()
+	return obj
+}
+
+func (r *Ring) PopWait(index Index) *channel.Waiter {
+	return popWait(context.Background(), r, index)
+}
+
+func (r *Ring) PopWaitContext(ctx context.Context, index Index) *channel.Waiter {
+	return popWait(ctx, r, index)
+}
+
+func (r *Ring) CancelAndClose(index Index) {
+	r.CancelWhere(index, func(interface{}) bool {
+		return true
+	})
+}
+
+// Cancel will cancel an item returned by AddStateful, its slot is released once popped.
+// Returns false for items of other queues
+func (r *Ring) Cancel(it Item) bool {
+	i, ok := it.(*item)
+	if !ok || i == nil || i.owner != r || !i.claim(ItemCancelled) {
+		return false
+	}
+	atomic.AddInt64(&r.count, -1)
+	i.Cancelled()
+	return true
+}
+
+// CancelWhere cancels the items currently in the ring for which the predicate returns true
+func (r *Ring) CancelWhere(index Index, predicate func(interface{}) bool) int {
+	if !isDefaultIndex(index) {
+		return 0
+	}
+
+	cancelled := 0
+	r.frontLock.Lock()
+	front := append([]*item(nil), r.front...)
+	r.frontLock.Unlock()
+	for _, i := range front {
+		if predicate(i.Item()) && r.Cancel(i) {
+			cancelled++
+		}
+	}
+
+	tail := atomic.LoadUint64(&r.tail)
+	for pos := atomic.LoadUint64(&r.head); pos < tail; pos++ {
+		slot := &r.slots[pos&r.mask]
+		if atomic.LoadUint64(&slot.seq) != pos+1 { // already popped or not yet added
+			continue
+		}
+		i := slot.item.Load().(*item)
+		if i != nil && predicate(i.Item()) && r.Cancel(i) {
+			cancelled++
+		}
+	}
+	return cancelled
+}
+
+// Len returns the number of items which are neither popped nor cancelled
+func (r *Ring) Len() int {
+	return int(atomic.LoadInt64(&r.count))
+}
+
+func (r *Ring) newItem(e interface{}) *item {
+	newPolicies := make([]policies.Policy, 0, len(r.policies))
+	for _, p := range r.policies {
+		newPolicies = append(newPolicies, p())
+	}
+	i := newItem(e, DefaultItemIndex, policies.NewPolicyManager(newPolicies))
+	i.owner = r
+	return i
+}
+
+func (r *Ring) tryAdd(i *item) bool {
+	for {
+		pos := atomic.LoadUint64(&r.tail)
+		slot := &r.slots[pos&r.mask]
+		diff := int64(atomic.LoadUint64(&slot.seq)) - int64(pos)
+		switch {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&r.tail, pos, pos+1) {
+				atomic.AddInt64(&r.count, 1)
+				slot.item.Store(i)
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return true
+			}
+		case diff < 0: // full, unless the head slot can be released
+			if !r.reclaim() {
+				return false
+			}
+		}
+	}
+}
+
+// reclaim releases the head slot if its item was cancelled or evicted, returns false if the head item is live or the ring is empty
+func (r *Ring) reclaim() bool {
+	pos := atomic.LoadUint64(&r.head)
+	slot := &r.slots[pos&r.mask]
+	if atomic.LoadUint64(&slot.seq) != pos+1 { // popped meanwhile or not yet added
+		return atomic.LoadUint64(&r.head) != pos
+	}
+	i := slot.item.Load().(*item)
+	if i == nil {
+		return false
+	}
+	if atomic.LoadInt32(&i.state) == 0 {
+		if !i.PolicyManager().Evacuate() || !i.claim(ItemEvicted) {
+			return false
+		}
+		atomic.AddInt64(&r.count, -1)
+		i.Evicted()
+	}
+	// a consumer popping the slot meanwhile releases it as well
+	if atomic.CompareAndSwapUint64(&r.head, pos, pos+1) {
+		slot.item.Store((*item)(nil))
+		atomic.StoreUint64(&slot.seq, pos+r.mask+1)
+	}
+	return true
+}
+
+// popItem returns the next item which wasn't cancelled or evicted without firing popped, nil if empty or index isn't the default one
+func (r *Ring) popItem(index Index) Item {
+	if !isDefaultIndex(index) {
+		return nil
+	}
+	if i := r.claimNext(); i != nil {
+		return i
+	}
+	return nil
+}
+
+// handBack puts a popped item in front of the slots' items, it's added even if the ring is full
+func (r *Ring) handBack(it Item) {
+	i := it.(*item)
+	r.frontLock.Lock()
+	defer r.frontLock.Unlock()
+
+	atomic.AddInt64(&r.count, 1)
+	atomic.StoreInt32(&i.state, 0) // so it can be claimed again
+	r.front = append(r.front, i)
+	atomic.AddInt64(&r.fronts, 1)
+}
+
+// pop returns the next item which wasn't cancelled or evicted, nil if empty
+func (r *Ring) pop() *item {
+	i := r.claimNext()
+	if i != nil {
+		i.Popped()
+	}
+	return i
+}
+
+// claimNext claims the next item which wasn't cancelled or evicted as popped, nil if empty
+func (r *Ring) claimNext() *item {
+	for {
+		i := r.popFront()
+		if i == nil {
+			i = r.dequeue()
+		}
+		if i == nil {
+			return nil
+		}
+		if i.PolicyManager().Evacuate() {
+			if i.claim(ItemEvicted) {
+				atomic.AddInt64(&r.count, -1)
+				i.Evicted()
+			}
+			continue
+		}
+		if i.claim(ItemPopped) {
+			atomic.AddInt64(&r.count, -1)
+			return i
+		}
+	}
+}
+
+// popFront returns the last handed back item, nil if none
+func (r *Ring) popFront() *item {
+	if atomic.LoadInt64(&r.fronts) == 0 {
+		return nil
+	}
+
+	r.frontLock.Lock()
+	defer r.frontLock.Unlock()
+	n := len(r.front)
+	if n == 0 {
+		return nil
+	}
+	i := r.front[n-1]
+	r.front[n-1] = nil
+	r.front = r.front[:n-1]
+	atomic.AddInt64(&r.fronts, -1)
+	return i
+}
+
+func (r *Ring) dequeue() *item {
+	for {
+		pos := atomic.LoadUint64(&r.head)
+		slot := &r.slots[pos&r.mask]
+		diff := int64(atomic.LoadUint64(&slot.seq)) - int64(pos+1)
+		switch {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&r.head, pos, pos+1) {
+				i := slot.item.Load().(*item)
+				slot.item.Store((*item)(nil))
+				atomic.StoreUint64(&slot.seq, pos+r.mask+1)
+				return i
+			}
+		case diff < 0: // empty
+			return nil
+		}
+	}
+}
+
+// backoff yields for the first attempts and then sleeps with a growing duration, returns an error if the context is done
+func (r *Ring) backoff(ctx context.Context, attempt int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if attempt < 16 {
+		runtime.Gosched()
+		return nil
+	}
+
+	d := time.Microsecond << uint(attempt-16)
+	if d > ringMaxBackoff || d <= 0 {
+		d = ringMaxBackoff
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func isDefaultIndex(index Index) bool {
+	return len(index) == 0 || index == DefaultItemIndex
+}
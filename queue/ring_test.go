@@ -0,0 +1,293 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bloxapp/go-threading/queue/policies"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingAddAndPop(t *testing.T) {
+	var q Queue = NewRing(4)
+	require.True(t, q.Add("first", ""))
+	require.True(t, q.Add("second", DefaultItemIndex))
+	require.False(t, q.Add("other", "index"))
+	require.EqualValues(t, 2, q.Len())
+
+	require.Nil(t, q.Pop("index"))
+	require.EqualValues(t, "first", q.Pop(DefaultItemIndex))
+	require.EqualValues(t, "second", q.Pop(""))
+	require.Nil(t, q.Pop(DefaultItemIndex))
+	require.EqualValues(t, 0, q.Len())
+}
+
+func TestRingCapacity(t *testing.T) {
+	r := NewRing(3)
+	require.EqualValues(t, 4, r.Cap())
+	for i := 0; i < r.Cap(); i++ {
+		require.True(t, r.TryAdd(i))
+	}
+	require.False(t, r.TryAdd("full"))
+
+	obj, ok := r.TryPop()
+	require.True(t, ok)
+	require.EqualValues(t, 0, obj)
+	require.True(t, r.TryAdd("not full"))
+}
+
+func TestRingTryPopNil(t *testing.T) {
+	r := NewRing(2)
+	_, ok := r.TryPop()
+	require.False(t, ok)
+
+	require.True(t, r.TryAdd(nil))
+	obj, ok := r.TryPop()
+	require.True(t, ok)
+	require.Nil(t, obj)
+}
+
+func TestRingCancel(t *testing.T) {
+	r := NewRing(8)
+	_, first := r.AddStateful(1, "")
+	_, second := r.AddStateful(2, "")
+	r.Add(3, "")
+	r.Add(4, "")
+
+	require.True(t, r.Cancel(second))
+	require.False(t, r.Cancel(second))
+	require.False(t, r.Cancel(nil))
+	require.EqualValues(t, ItemCancelled, receive(t, second.Waiter()))
+	require.EqualValues(t, 3, r.Len())
+
+	require.EqualValues(t, 1, r.CancelWhere("", func(obj interface{}) bool {
+		return obj.(int) == 3
+	}))
+	require.EqualValues(t, 2, r.Len())
+
+	require.EqualValues(t, 1, r.Pop(""))
+	require.EqualValues(t, ItemPopped, receive(t, first.Waiter()))
+	require.False(t, r.Cancel(first))
+	require.EqualValues(t, 4, r.Pop(""))
+	require.Nil(t, r.Pop(""))
+
+	r.Add(5, "")
+	r.CancelAndClose("")
+	require.Nil(t, r.Pop(""))
+	require.EqualValues(t, 0, r.Len())
+}
+
+func TestRingAddAfterCancel(t *testing.T) {
+	t.Run("cancel", func(t *testing.T) {
+		r := NewRing(2)
+		_, i1 := r.AddStateful(1, "")
+		_, i2 := r.AddStateful(2, "")
+		require.False(t, r.Add(3, ""))
+		require.True(t, r.Cancel(i1))
+		require.True(t, r.Cancel(i2))
+		require.EqualValues(t, 0, r.Len())
+
+		// cancelled head slots are released by adds, no pops needed
+		require.True(t, r.Add(3, ""))
+		require.True(t, r.Add(4, ""))
+		require.False(t, r.Add(5, ""))
+		require.EqualValues(t, 3, r.Pop(""))
+	})
+
+	t.Run("cancel where", func(t *testing.T) {
+		r := NewRing(2)
+		r.Add(1, "")
+		r.Add(2, "")
+		require.EqualValues(t, 2, r.CancelWhere("", func(interface{}) bool {
+			return true
+		}))
+		require.True(t, r.Add(3, ""))
+		require.True(t, r.Add(4, ""))
+		require.EqualValues(t, 2, r.Len())
+	})
+
+	t.Run("evicted", func(t *testing.T) {
+		r := NewRing(2, policies.TimeOutPolicy(time.Millisecond*25))
+		r.Add(1, "")
+		r.Add(2, "")
+		time.Sleep(time.Millisecond * 50)
+		require.True(t, r.Add(3, ""))
+		require.EqualValues(t, 3, r.Pop(""))
+		require.EqualValues(t, 0, r.Len())
+	})
+}
+
+func TestRingCancelOtherQueues(t *testing.T) {
+	r := NewRing(8)
+	r.Add(1, "")
+
+	_, other := NewRing(8).AddStateful(1, "")
+	_, queued := New(FIFO, 8).AddStateful(1, "")
+	require.False(t, r.Cancel(other))
+	require.False(t, r.Cancel(queued))
+	require.False(t, r.Cancel(NewItem(1, nil)))
+	require.EqualValues(t, 1, r.Len())
+}
+
+func TestRingEviction(t *testing.T) {
+	r := NewRing(4, policies.TimeOutPolicy(time.Millisecond*25))
+	r.Add("old", "")
+	time.Sleep(time.Millisecond * 50)
+	r.Add("new", "")
+	require.EqualValues(t, "new", r.Pop(""))
+	require.Nil(t, r.Pop(""))
+	require.EqualValues(t, 0, r.Len())
+}
+
+func TestRingBlocking(t *testing.T) {
+	t.Run("pop waits for add", func(t *testing.T) {
+		r := NewRing(2)
+		go func() {
+			time.Sleep(time.Millisecond * 25)
+			r.TryAdd("item")
+		}()
+		obj, err := r.PopContext(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, "item", obj)
+	})
+
+	t.Run("add waits for pop", func(t *testing.T) {
+		r := NewRing(2)
+		r.TryAdd(1)
+		r.TryAdd(2)
+		go func() {
+			time.Sleep(time.Millisecond * 25)
+			r.TryPop()
+		}()
+		require.NoError(t, r.AddContext(context.Background(), 3))
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		r := NewRing(2)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*25)
+		defer cancel()
+		_, err := r.PopContext(ctx)
+		require.EqualError(t, err, context.DeadlineExceeded.Error())
+
+		r.TryAdd(1)
+		r.TryAdd(2)
+		require.EqualError(t, r.AddContext(ctx, 3), context.DeadlineExceeded.Error())
+	})
+}
+
+func TestRingConcurrency(t *testing.T) {
+	r := NewRing(64)
+	const producers, consumers, perProducer = 8, 8, 2000
+
+	var sum, popped int64
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumersWg := sync.WaitGroup{}
+	for c := 0; c < consumers; c++ {
+		consumersWg.Add(1)
+		go func() {
+			defer consumersWg.Done()
+			for {
+				obj, err := r.PopContext(ctx)
+				if err != nil {
+					return
+				}
+				atomic.AddInt64(&sum, int64(obj.(int)))
+				if atomic.AddInt64(&popped, 1) == producers*perProducer {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	producersWg := sync.WaitGroup{}
+	for p := 0; p < producers; p++ {
+		producersWg.Add(1)
+		go func() {
+			defer producersWg.Done()
+			for i := 1; i <= perProducer; i++ {
+				require.NoError(t, r.AddContext(context.Background(), i))
+			}
+		}()
+	}
+
+	producersWg.Wait()
+	consumersWg.Wait()
+	require.EqualValues(t, producers*perProducer, popped)
+	require.EqualValues(t, producers*perProducer*(perProducer+1)/2, sum)
+	require.EqualValues(t, 0, r.Len())
+}
+
+func TestRingConcurrentCancel(t *testing.T) {
+	r := NewRing(1024)
+	items := make([]Item, 0)
+	for i := 0; i < 1000; i++ {
+		_, item := r.AddStateful(i, "")
+		items = append(items, item)
+	}
+
+	var popped, cancelled int64
+	wg := sync.WaitGroup{}
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for r.Pop("") != nil {
+				atomic.AddInt64(&popped, 1)
+			}
+		}()
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(items); i += 4 {
+				if r.Cancel(items[i]) {
+					atomic.AddInt64(&cancelled, 1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for r.Pop("") != nil {
+		popped++
+	}
+	require.EqualValues(t, 1000, popped+cancelled)
+	require.EqualValues(t, 0, r.Len())
+}
+
+func BenchmarkRing(b *testing.B) {
+	impls := []struct {
+		name string
+		newQ func() Queue
+	}{
+		{"mutex", func() Queue { return New(FIFO, 1<<20) }},
+		{"ring", func() Queue { return NewRing(1 << 20) }},
+	}
+
+	for _, impl := range impls {
+		for _, goroutines := range []int{1, 8, 64} {
+			b.Run(fmt.Sprintf("%s/goroutines-%d", impl.name, goroutines), func(b *testing.B) {
+				q := impl.newQ()
+				wg := sync.WaitGroup{}
+				perGoroutine := b.N/goroutines + 1
+				b.ResetTimer()
+				for g := 0; g < goroutines; g++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := 0; i < perGoroutine; i++ {
+							q.Add(true, DefaultItemIndex)
+							q.Pop(DefaultItemIndex)
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
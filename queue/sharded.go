@@ -0,0 +1,336 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bloxapp/go-threading/channel"
+	"github.com/bloxapp/go-threading/queue/policies"
+)
+
+// shardedQueue is a thread safe implementation of Queue which locks every index separately.
+// Eviction is scoped to the popped index, a full queue evicts index by index until there is room for a new item.
+type shardedQueue struct {
+	lock      sync.RWMutex // lock guards the shards map, not the shards themselves
+	shards    map[Index]*shard
+	policies  []policies.ApplyPolicy
+	capacity  int64
+	direction Direction
+	count     int64 // count is the total number of items, accessed atomically
+}
+
+// shard holds the items of a single index
+type shard struct {
+	lock  sync.Mutex
+	items []Item
+	dead  bool // dead is true once the shard was removed from the shards map
+}
+
+// NewSharded returns a new instance of a lock striped Queue, suited for many indexes accessed concurrently
+func NewSharded(direction Direction, capacity int, policies ...policies.ApplyPolicy) Queue {
+	return &shardedQueue{
+		shards:    make(map[Index]*shard),
+		lock:      sync.RWMutex{},
+		capacity:  int64(capacity),
+		direction: direction,
+		policies:  policies,
+	}
+}
+
+// Add will add an item to the queue, thread safe.
+func (q *shardedQueue) Add(e interface{}, index Index) bool {
+	res, _ := q.add(e, index)
+	return res
+}
+
+func (q *shardedQueue) AddStateful(e interface{}, index Index) (bool, Item) {
+	return q.add(e, index)
+}
+
+// Pop will return and delete an item from the index, thread safe.
+func (q *shardedQueue) Pop(index Index) interface{} {
+	ret := q.popItem(index)
+	if ret == nil {
+		return nil
+	}
+
+	// fire popped
+	ret.Popped()
+
+	return ret.Item()
+}
+
+// popItem deletes and returns the next item of the index, nil if none
+func (q *shardedQueue) popItem(index Index) Item {
+	if len(index) == 0 {
+		index = DefaultItemIndex
+	}
+
+	s := q.getShard(index, false)
+	if s == nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	count, evictedItems := q.evictShard(s)
+	if count == 0 {
+		s.lock.Unlock()
+		q.removeIfEmpty(index, s)
+		evicted(evictedItems)
+		return nil
+	}
+
+	qLen := len(s.items)
+	var ret Item
+	if q.direction == FIFO {
+		ret = s.items[0]
+		s.items[0] = nil
+		s.items = s.items[1:qLen]
+	} else { // LIFO
+		ret = s.items[qLen-1]
+		s.items[qLen-1] = nil
+		s.items = s.items[0 : qLen-1]
+	}
+	atomic.AddInt64(&q.count, -1)
+	empty := len(s.items) == 0
+	s.lock.Unlock()
+
+	if empty {
+		q.removeIfEmpty(index, s)
+	}
+
+	// call evicted
+	evicted(evictedItems)
+
+	return ret
+}
+
+// handBack re-inserts a popped item where it was popped from, it's added even if the queue is at capacity
+func (q *shardedQueue) handBack(item Item) {
+	atomic.AddInt64(&q.count, 1)
+	for {
+		s := q.getShard(item.Index(), true)
+		s.lock.Lock()
+		if s.dead { // removed while we were waiting for the lock, get a new one
+			s.lock.Unlock()
+			continue
+		}
+		if q.direction == FIFO {
+			s.items = append([]Item{item}, s.items...)
+		} else { // LIFO
+			s.items = append(s.items, item)
+		}
+		s.lock.Unlock()
+		return
+	}
+}
+
+func (q *shardedQueue) PopWait(index Index) *channel.Waiter {
+	return popWait(context.Background(), q, index)
+}
+
+func (q *shardedQueue) PopWaitContext(ctx context.Context, index Index) *channel.Waiter {
+	return popWait(ctx, q, index)
+}
+
+func (q *shardedQueue) CancelAndClose(index Index) {
+	q.CancelWhere(index, func(interface{}) bool {
+		return true
+	})
+}
+
+func (q *shardedQueue) Cancel(item Item) bool {
+	if item == nil {
+		return false
+	}
+
+	s := q.getShard(item.Index(), false)
+	if s == nil {
+		return false
+	}
+
+	s.lock.Lock()
+	found := false
+	for idx, i := range s.items {
+		if i != item {
+			continue
+		}
+		s.items = append(s.items[:idx:idx], s.items[idx+1:]...)
+		atomic.AddInt64(&q.count, -1)
+		found = true
+		break
+	}
+	empty := len(s.items) == 0
+	s.lock.Unlock()
+
+	if empty {
+		q.removeIfEmpty(item.Index(), s)
+	}
+	if found {
+		// call cancelled
+		item.Cancelled()
+	}
+	return found
+}
+
+func (q *shardedQueue) CancelWhere(index Index, predicate func(interface{}) bool) int {
+	if len(index) == 0 {
+		index = DefaultItemIndex
+	}
+
+	s := q.getShard(index, false)
+	if s == nil {
+		return 0
+	}
+
+	s.lock.Lock()
+	newItems := make([]Item, 0, len(s.items))
+	cancelled := make([]Item, 0)
+	for _, i := range s.items {
+		if predicate(i.Item()) {
+			cancelled = append(cancelled, i)
+		} else {
+			newItems = append(newItems, i)
+		}
+	}
+	s.items = newItems
+	atomic.AddInt64(&q.count, -int64(len(cancelled)))
+	s.lock.Unlock()
+
+	q.removeIfEmpty(index, s)
+
+	// call cancelled on items once unlocked
+	for _, i := range cancelled {
+		i.Cancelled()
+	}
+	return len(cancelled)
+}
+
+func (q *shardedQueue) Len() int {
+	return int(atomic.LoadInt64(&q.count))
+}
+
+// getShard returns the shard of the index, if create is true a missing shard will be created
+func (q *shardedQueue) getShard(index Index, create bool) *shard {
+	q.lock.RLock()
+	s := q.shards[index]
+	q.lock.RUnlock()
+	if s != nil || !create {
+		return s
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if s = q.shards[index]; s == nil {
+		s = &shard{items: make([]Item, 0)}
+		q.shards[index] = s
+	}
+	return s
+}
+
+// removeIfEmpty deletes the shard from the shards map if it has no items
+func (q *shardedQueue) removeIfEmpty(index Index, s *shard) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.dead || len(s.items) > 0 || q.shards[index] != s {
+		return
+	}
+	s.dead = true
+	delete(q.shards, index)
+}
+
+// evictShard evicts the shard's items according to policy and returns its (after eviction) length and the evicted items,
+// Evicted should be called on them once unlocked
+// not thread safe, should be called while holding the shard's lock
+func (q *shardedQueue) evictShard(s *shard) (int, []Item) {
+	newItems := make([]Item, 0, len(s.items))
+	evictedItems := make([]Item, 0)
+	for _, i := range s.items {
+		if !i.PolicyManager().Evacuate() {
+			newItems = append(newItems, i)
+		} else {
+			evictedItems = append(evictedItems, i)
+		}
+	}
+	atomic.AddInt64(&q.count, -int64(len(evictedItems)))
+	s.items = newItems
+	return len(newItems), evictedItems
+}
+
+// evictAll evicts shard by shard and removes the emptied shards, stops once there is room for a new item
+func (q *shardedQueue) evictAll() {
+	q.lock.RLock()
+	shards := make(map[Index]*shard, len(q.shards))
+	for index, s := range q.shards {
+		shards[index] = s
+	}
+	q.lock.RUnlock()
+
+	for index, s := range shards {
+		s.lock.Lock()
+		count, evictedItems := q.evictShard(s)
+		s.lock.Unlock()
+		if count == 0 {
+			q.removeIfEmpty(index, s)
+		}
+		evicted(evictedItems)
+		if atomic.LoadInt64(&q.count) < q.capacity {
+			return
+		}
+	}
+}
+
+// reserve will return true if the count was incremented without exceeding capacity
+func (q *shardedQueue) reserve() bool {
+	evicted := false
+	for {
+		cnt := atomic.LoadInt64(&q.count)
+		if cnt+1 > q.capacity {
+			if evicted {
+				return false
+			}
+			q.evictAll()
+			evicted = true
+			continue
+		}
+		if atomic.CompareAndSwapInt64(&q.count, cnt, cnt+1) {
+			return true
+		}
+	}
+}
+
+func (q *shardedQueue) add(e interface{}, index Index) (bool, Item) {
+	if !q.reserve() {
+		return false, nil
+	}
+
+	if len(index) == 0 {
+		index = DefaultItemIndex
+	}
+
+	// set policies
+	newPolicies := make([]policies.Policy, 0)
+	for _, p := range q.policies {
+		newPolicies = append(newPolicies, p())
+	}
+
+	// generate item
+	i := newItem(e, index, policies.NewPolicyManager(newPolicies))
+
+	for {
+		s := q.getShard(index, true)
+		s.lock.Lock()
+		if s.dead { // removed while we were waiting for the lock, get a new one
+			s.lock.Unlock()
+			continue
+		}
+		s.items = append(s.items, i)
+		s.lock.Unlock()
+		return true, i
+	}
+}
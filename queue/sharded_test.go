@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bloxapp/go-threading/queue/policies"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedAddAndPop(t *testing.T) {
+	q := NewSharded(FIFO, 10)
+	require.True(t, q.Add(true, ""))
+	require.True(t, q.Pop(DefaultItemIndex).(bool))
+	require.EqualValues(t, 0, q.Len())
+	require.Nil(t, q.Pop(DefaultItemIndex))
+	require.Len(t, q.(*shardedQueue).shards, 0)
+}
+
+func TestShardedDirection(t *testing.T) {
+	t.Run("fifo", func(t *testing.T) {
+		q := NewSharded(FIFO, 10)
+		q.Add("first", "index")
+		q.Add("second", "index")
+		q.Add("third", "index")
+
+		require.EqualValues(t, "first", q.Pop("index"))
+		require.EqualValues(t, "second", q.Pop("index"))
+		require.EqualValues(t, "third", q.Pop("index"))
+	})
+
+	t.Run("lifo", func(t *testing.T) {
+		q := NewSharded(LIFO, 10)
+		q.Add("first", "index")
+		q.Add("second", "index")
+		q.Add("third", "index")
+
+		require.EqualValues(t, "third", q.Pop("index"))
+		require.EqualValues(t, "second", q.Pop("index"))
+		require.EqualValues(t, "first", q.Pop("index"))
+	})
+}
+
+func TestShardedAddWhenFull(t *testing.T) {
+	t.Run("multiple adds > capacity", func(t *testing.T) {
+		q := NewSharded(FIFO, 3)
+		require.True(t, q.Add("item", "index"))
+		require.True(t, q.Add("item", "index2"))
+		require.True(t, q.Add("item", "index3"))
+		require.False(t, q.Add("item", "index4"))
+		require.EqualValues(t, 3, q.Len())
+	})
+
+	t.Run("multiple indexes on immediate eviction", func(t *testing.T) {
+		q := NewSharded(FIFO, 3, evictImmediatelyF())
+		for i := 0; i < 100; i++ {
+			require.True(t, q.Add("item2", Index(fmt.Sprintf("index_%d", i))))
+		}
+	})
+
+	t.Run("timeout policy", func(t *testing.T) {
+		q := NewSharded(FIFO, 2, policies.TimeOutPolicy(time.Millisecond*25))
+		require.True(t, q.Add("item", "index"))
+		require.True(t, q.Add("item", "index2"))
+		require.False(t, q.Add("item", "index3"))
+		time.Sleep(time.Millisecond * 50)
+		require.True(t, q.Add("item", "index3"))
+		require.True(t, q.Add("item", "index4")) // evicts the other timed out index
+		// shards emptied by eviction are removed
+		require.Len(t, q.(*shardedQueue).shards, 2)
+		require.Nil(t, q.Pop("index"))
+		require.EqualValues(t, "item", q.Pop("index3"))
+		require.Len(t, q.(*shardedQueue).shards, 1)
+	})
+}
+
+func TestShardedCancel(t *testing.T) {
+	q := NewSharded(FIFO, 10)
+	_, first := q.AddStateful(1, "index")
+	_, second := q.AddStateful(2, "index")
+	q.Add(3, "index")
+	q.Add(4, "other")
+
+	require.True(t, q.Cancel(second))
+	require.False(t, q.Cancel(second))
+	require.EqualValues(t, ItemCancelled, receive(t, second.Waiter()))
+
+	require.EqualValues(t, 1, q.CancelWhere("index", func(obj interface{}) bool {
+		return obj.(int) == 3
+	}))
+	require.EqualValues(t, 2, q.Len())
+
+	require.EqualValues(t, 1, q.Pop("index"))
+	require.EqualValues(t, ItemPopped, receive(t, first.Waiter()))
+	require.Nil(t, q.Pop("index"))
+
+	q.CancelAndClose("other")
+	require.Nil(t, q.Pop("other"))
+	require.EqualValues(t, 0, q.Len())
+}
+
+func TestShardedConcurrency(t *testing.T) {
+	q := NewSharded(FIFO, 1000)
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				index := Index(fmt.Sprintf("index_%d", (g+i)%16))
+				q.Add(i, index)
+				q.Pop(index)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := 0; i < 16; i++ {
+		for q.Pop(Index(fmt.Sprintf("index_%d", i))) != nil {
+		}
+	}
+	require.EqualValues(t, 0, q.Len())
+}
+
+func BenchmarkQueues(b *testing.B) {
+	impls := []struct {
+		name string
+		newQ func() Queue
+	}{
+		{"mutex", func() Queue { return New(FIFO, 1<<20) }},
+		{"sharded", func() Queue { return NewSharded(FIFO, 1<<20) }},
+	}
+
+	indexes := make([]Index, 1024)
+	for i := range indexes {
+		indexes[i] = Index(fmt.Sprintf("index_%d", i))
+	}
+
+	for _, impl := range impls {
+		for _, goroutines := range []int{1, 8, 64} {
+			b.Run(fmt.Sprintf("%s/goroutines-%d", impl.name, goroutines), func(b *testing.B) {
+				q := impl.newQ()
+				// pre fill all indexes so pops don't hit empty indexes
+				for _, index := range indexes {
+					q.Add(true, index)
+				}
+
+				wg := sync.WaitGroup{}
+				perGoroutine := b.N/goroutines + 1
+				b.ResetTimer()
+				for g := 0; g < goroutines; g++ {
+					wg.Add(1)
+					go func(g int) {
+						defer wg.Done()
+						for i := 0; i < perGoroutine; i++ {
+							index := indexes[(g*perGoroutine+i)%len(indexes)]
+							q.Add(true, index)
+							q.Pop(index)
+						}
+					}(g)
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package timer
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -11,58 +12,124 @@ const (
 	waiterTimout = time.Millisecond * 50
 )
 
+// ResultType is the reason a RoundTimer result was fired
+type ResultType int
+
+const (
+	// Lapsed is fired when the timer's deadline passed
+	Lapsed ResultType = iota + 1
+	// Stopped is fired when the timer was stopped by Stop or by the context passed to ResetContext
+	Stopped
+	// Killed is fired when the timer was killed
+	Killed
+	// Reset is fired when the timer was reset before its deadline passed
+	Reset
+)
+
+func (r ResultType) String() string {
+	switch r {
+	case Lapsed:
+		return "lapsed"
+	case Stopped:
+		return "stopped"
+	case Killed:
+		return "killed"
+	case Reset:
+		return "reset"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is fired to the timer's result waiters
+type Result struct {
+	Type ResultType
+	// Round is the round the timer was set for, see WithRound
+	Round uint64
+}
+
+// ResetOption configures a reset
+type ResetOption func(r *resetConfig)
+
+// WithRound sets the round of the reset, reported by the round's Result.
+// Without it the round following the previous one is used, starting at 1
+func WithRound(round uint64) ResetOption {
+	return func(r *resetConfig) {
+		r.round = round
+		r.hasRound = true
+	}
+}
+
+type resetConfig struct {
+	round    uint64
+	hasRound bool
+}
+
 // RoundTimer is a wrapper around timer to fit the use in an iBFT instance
 type RoundTimer struct {
-	timer     *time.Timer
-	internalC *channel.Channel // internalC triggers when lapsed or cancelled
-	resC      *channel.Channel
+	timer   *time.Timer
+	resC    *channel.TypedChannel[bool]
+	results *channel.TypedChannel[Result]
+	round   uint64
+	epoch   uint64        // epoch is incremented on every reset, so results of ended rounds are never fired even if rounds repeat
+	watch   chan struct{} // watch is closed to stop watching the context of the current round, nil if not watching
 
 	stopped  bool
+	killed   bool
 	syncLock sync.RWMutex
 }
 
 // New returns a new instance of RoundTimer
 func New() *RoundTimer {
 	return &RoundTimer{
-		timer:     nil,
-		internalC: channel.New(),
-		resC:      channel.New(),
-		stopped:   true,
-		syncLock:  sync.RWMutex{},
+		timer:    nil,
+		resC:     channel.NewTyped[bool](),
+		results:  channel.NewTyped[Result](),
+		stopped:  true,
+		syncLock: sync.RWMutex{},
 	}
 }
 
 // ResultChan returns the result chan
-// true if the timer lapsed or false if it was stopped
-func (t *RoundTimer) ResultChan() *channel.Waiter {
+// true if the timer lapsed or false if it was killed.
+// The waiter stays registered until closed, call Close on it once no longer needed
+//
+// Deprecated: use Results, which tells the round and all the reasons a round ended
+func (t *RoundTimer) ResultChan() *channel.TypedWaiter[bool] {
 	t.syncLock.Lock()
 	defer t.syncLock.Unlock()
 	return t.resC.Register()
 }
 
-// Reset will return a channel that sends true if the timer lapsed or false if it was cancelled
-// If Start is called more than once, the first timer and chan are returned and used
-func (t *RoundTimer) Reset(d time.Duration) {
+// Results returns a waiter receiving a Result whenever a round ends (lapsed, stopped, killed or reset).
+// The waiter stays registered until closed, call Close on it once no longer needed
+func (t *RoundTimer) Results() *channel.TypedWaiter[Result] {
 	t.syncLock.Lock()
 	defer t.syncLock.Unlock()
+	return t.results.Register()
+}
 
-	t.stopped = false
+// Reset starts a new round which lapses after d, a running round is ended with a Reset result.
+// Resets of a killed timer are ignored
+func (t *RoundTimer) Reset(d time.Duration, opts ...ResetOption) {
+	t.reset(context.Background(), d, opts)
+}
 
-	if t.timer != nil {
-		// timer is already running, reset it.
-		t.timer.Stop()
-		t.timer.Reset(d)
-	} else {
-		// no running timer, create a new one
-		go t.eventLoop()
-		t.timer = time.AfterFunc(d, func() {
-			t.syncLock.Lock()
-			defer t.syncLock.Unlock()
-			t.internalC.FireToAll(true)
-			t.timer = nil
-			t.stopEventLoop()
-		})
-	}
+// ResetAt is like Reset with a deadline
+func (t *RoundTimer) ResetAt(deadline time.Time, opts ...ResetOption) {
+	t.reset(context.Background(), time.Until(deadline), opts)
+}
+
+// ResetContext is like Reset but the round is stopped (with a Stopped result) once the context is done
+func (t *RoundTimer) ResetContext(ctx context.Context, d time.Duration, opts ...ResetOption) {
+	t.reset(ctx, d, opts)
+}
+
+// Round returns the round of the last reset, 0 if the timer was never reset
+func (t *RoundTimer) Round() uint64 {
+	t.syncLock.RLock()
+	defer t.syncLock.RUnlock()
+	return t.round
 }
 
 // Stopped returns true if there is no running timer
@@ -72,41 +139,114 @@ func (t *RoundTimer) Stopped() bool {
 	return t.stopped
 }
 
-// Kill will stop the timer (without the ability to restart it) and send false on the result chan
+// Stop ends the running round (if any) with a Stopped result, the timer can be reset again
+func (t *RoundTimer) Stop() {
+	t.syncLock.Lock()
+	if t.stopped {
+		t.syncLock.Unlock()
+		return
+	}
+	round := t.endRound()
+	t.syncLock.Unlock()
+
+	t.results.FireToAll(Result{Type: Stopped, Round: round})
+}
+
+// Kill will stop the timer (without the ability to restart it) and send false on the result chan, only the first call has an effect
 func (t *RoundTimer) Kill() {
 	t.syncLock.Lock()
+	if t.killed {
+		t.syncLock.Unlock()
+		return
+	}
+	t.killed = true
+	round := t.endRound()
+	t.syncLock.Unlock()
 
-	if t.timer != nil {
-		t.timer.Stop()
+	t.results.FireToAll(Result{Type: Killed, Round: round})
+	t.resC.FireToAll(false)
+}
+
+func (t *RoundTimer) reset(ctx context.Context, d time.Duration, opts []ResetOption) {
+	cfg := &resetConfig{}
+	for _, opt := range opts {
+		opt(cfg)
 	}
-	t.stopEventLoop()
 
+	t.syncLock.Lock()
+	if t.killed {
+		t.syncLock.Unlock()
+		return
+	}
+	running := !t.stopped
+	prev := t.endRound()
+
+	if cfg.hasRound {
+		t.round = cfg.round
+	} else {
+		t.round++
+	}
+	t.epoch++
+	epoch := t.epoch
+	t.stopped = false
+	t.timer = time.AfterFunc(d, func() {
+		t.lapse(epoch)
+	})
+	if ctx.Done() != nil { // the context can be done
+		t.watch = make(chan struct{})
+		go t.watchContext(ctx, epoch, t.watch)
+	}
 	t.syncLock.Unlock()
 
-	t.fireChannelEvent(false)
+	if running {
+		t.results.FireToAll(Result{Type: Reset, Round: prev})
+	}
 }
 
-func (t *RoundTimer) fireChannelEvent(value bool) {
-	t.syncLock.RLock()
-	defer t.syncLock.RUnlock()
+// lapse ends the round of the epoch if it's still running
+func (t *RoundTimer) lapse(epoch uint64) {
+	t.syncLock.Lock()
+	if t.stopped || t.epoch != epoch {
+		t.syncLock.Unlock()
+		return
+	}
+	round := t.endRound()
+	t.syncLock.Unlock()
 
-	t.resC.FireToAll(value)
+	t.results.FireToAll(Result{Type: Lapsed, Round: round})
+	t.resC.FireToAll(true)
 }
 
-func (t *RoundTimer) stopEventLoop() {
-	t.stopped = true
-	t.internalC.FireToAll(false)
-}
-
-func (t *RoundTimer) eventLoop() {
-	waiter := t.internalC.Register()
-loop:
-	for {
-		res := waiter.Wait()
-		if res.(bool) { // lapsed
-			t.fireChannelEvent(true)
-		} else { // killed
-			break loop
-		}
+// watchContext stops the round of the epoch once the context is done, until the round ended
+func (t *RoundTimer) watchContext(ctx context.Context, epoch uint64, watch chan struct{}) {
+	select {
+	case <-watch:
+		return
+	case <-ctx.Done():
+	}
+
+	t.syncLock.Lock()
+	if t.stopped || t.epoch != epoch {
+		t.syncLock.Unlock()
+		return
+	}
+	round := t.endRound()
+	t.syncLock.Unlock()
+
+	t.results.FireToAll(Result{Type: Stopped, Round: round})
+}
+
+// endRound stops the timer and returns the current round
+// not thread safe, should be called while holding the lock
+func (t *RoundTimer) endRound() uint64 {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
 	}
+	if t.watch != nil {
+		close(t.watch)
+		t.watch = nil
+	}
+	t.stopped = true
+	return t.round
 }
@@ -1,12 +1,15 @@
 package timer
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
 	"go.uber.org/goleak"
 
+	"github.com/bloxapp/go-threading/channel"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,7 +20,7 @@ func TestLeaks(t *testing.T) {
 			wg.Add(1)
 			timer := New()
 			go func(timer *RoundTimer) {
-				timer.ResultChan().Wait()
+				timer.ResultChan().Receive()
 				wg.Done()
 			}(timer)
 			timer.Reset(time.Millisecond * 25)
@@ -33,7 +36,7 @@ func TestLeaks(t *testing.T) {
 			wg.Add(1)
 			timer := New()
 			go func(timer *RoundTimer) {
-				timer.ResultChan().Wait()
+				timer.ResultChan().Receive()
 				wg.Done()
 			}(timer)
 			timer.Reset(time.Millisecond * 150)
@@ -67,24 +70,24 @@ func TestRoundTimer_Reset(t *testing.T) {
 	timer := New()
 	timer.Reset(time.Millisecond * 100)
 	require.False(t, timer.Stopped())
-	res := timer.ResultChan().Wait()
-	require.True(t, res.(bool))
+	res, _ := timer.ResultChan().Receive()
+	require.True(t, res)
 	require.True(t, timer.Stopped())
 
 	timer.Reset(time.Millisecond * 100)
 	require.False(t, timer.Stopped())
-	res = timer.ResultChan().Wait()
-	require.True(t, res.(bool))
+	res, _ = timer.ResultChan().Receive()
+	require.True(t, res)
 	require.True(t, timer.Stopped())
 }
 
 func TestRoundTimer_ResetTwice(t *testing.T) {
 	timer := New()
 	timer.Reset(time.Millisecond * 100)
-	timer.ResultChan().Wait()
+	timer.ResultChan().Receive()
 	timer.Reset(time.Millisecond * 100)
-	res := timer.ResultChan().Wait()
-	require.True(t, res.(bool))
+	res, _ := timer.ResultChan().Receive()
+	require.True(t, res)
 }
 
 func TestRoundTimer_ResetBeforeLapsed(t *testing.T) {
@@ -93,9 +96,9 @@ func TestRoundTimer_ResetBeforeLapsed(t *testing.T) {
 	timer.Reset(time.Millisecond * 300)
 
 	t1 := time.Now()
-	res := timer.ResultChan().Wait()
+	res, _ := timer.ResultChan().Receive()
 	t2 := time.Since(t1)
-	require.True(t, res.(bool))
+	require.True(t, res)
 	require.Greater(t, t2.Milliseconds(), (time.Millisecond * 150).Milliseconds())
 }
 
@@ -106,5 +109,119 @@ func TestRoundTimer_Stop(t *testing.T) {
 		time.Sleep(time.Millisecond * 100)
 		timer.Kill()
 	}()
-	require.False(t, timer.ResultChan().Wait().(bool))
+	res, _ := timer.ResultChan().Receive()
+	require.False(t, res)
+}
+
+// nextResult waits for the next result of w
+func nextResult(t *testing.T, w *channel.TypedWaiter[Result]) Result {
+	res, err := w.WaitTimeout(time.Second)
+	require.NoError(t, err)
+	return res
+}
+
+func TestRoundTimer_Results(t *testing.T) {
+	timer := New()
+	results := timer.Results()
+	defer results.Close()
+	require.EqualValues(t, 0, timer.Round())
+
+	timer.Reset(time.Millisecond * 10)
+	require.EqualValues(t, Result{Type: Lapsed, Round: 1}, nextResult(t, results))
+
+	timer.Reset(time.Second)
+	timer.Reset(time.Millisecond * 10)
+	require.EqualValues(t, Result{Type: Reset, Round: 2}, nextResult(t, results))
+	require.EqualValues(t, Result{Type: Lapsed, Round: 3}, nextResult(t, results))
+
+	timer.Reset(time.Second)
+	timer.Stop()
+	timer.Stop() // no result once stopped
+	require.EqualValues(t, Result{Type: Stopped, Round: 4}, nextResult(t, results))
+	require.True(t, timer.Stopped())
+
+	timer.Reset(time.Second)
+	timer.Kill()
+	require.EqualValues(t, Result{Type: Killed, Round: 5}, nextResult(t, results))
+	require.EqualValues(t, 5, timer.Round())
+	require.EqualValues(t, "killed", Killed.String())
+}
+
+func TestRoundTimer_ResetAt(t *testing.T) {
+	timer := New()
+	results := timer.Results()
+	defer results.Close()
+
+	start := time.Now()
+	timer.ResetAt(start.Add(time.Millisecond * 50))
+	require.EqualValues(t, Result{Type: Lapsed, Round: 1}, nextResult(t, results))
+	require.GreaterOrEqual(t, time.Since(start), time.Millisecond*50)
+
+	// a passed deadline lapses right away
+	timer.ResetAt(start)
+	require.EqualValues(t, Result{Type: Lapsed, Round: 2}, nextResult(t, results))
+}
+
+func TestRoundTimer_ResetContext(t *testing.T) {
+	timer := New()
+	results := timer.Results()
+	defer results.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timer.ResetContext(ctx, time.Second)
+	cancel()
+	require.EqualValues(t, Result{Type: Stopped, Round: 1}, nextResult(t, results))
+	require.True(t, timer.Stopped())
+
+	// the context only applies to its round
+	ctx, cancel = context.WithCancel(context.Background())
+	timer.ResetContext(ctx, time.Second)
+	timer.Reset(time.Millisecond * 20)
+	cancel()
+	require.EqualValues(t, Result{Type: Reset, Round: 2}, nextResult(t, results))
+	require.EqualValues(t, Result{Type: Lapsed, Round: 3}, nextResult(t, results))
+
+	goleak.VerifyNone(t)
+}
+
+func TestRoundTimer_WithRound(t *testing.T) {
+	timer := New()
+	results := timer.Results()
+	defer results.Close()
+
+	timer.Reset(time.Second, WithRound(7))
+	require.EqualValues(t, 7, timer.Round())
+	// resetting the same round ends the previous reset of it
+	timer.ResetAt(time.Now().Add(time.Millisecond*10), WithRound(7))
+	require.EqualValues(t, Result{Type: Reset, Round: 7}, nextResult(t, results))
+	require.EqualValues(t, Result{Type: Lapsed, Round: 7}, nextResult(t, results))
+
+	// rounds continue from the last one
+	timer.Reset(time.Millisecond * 10)
+	require.EqualValues(t, Result{Type: Lapsed, Round: 8}, nextResult(t, results))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timer.ResetContext(ctx, time.Second, WithRound(2))
+	cancel()
+	require.EqualValues(t, Result{Type: Stopped, Round: 2}, nextResult(t, results))
+}
+
+func TestRoundTimer_KillIsTerminal(t *testing.T) {
+	timer := New()
+	results := timer.Results()
+	defer results.Close()
+
+	timer.Reset(time.Second, WithRound(3))
+	timer.Kill()
+	require.EqualValues(t, Result{Type: Killed, Round: 3}, nextResult(t, results))
+
+	timer.Reset(time.Millisecond * 10)
+	timer.ResetAt(time.Now().Add(time.Millisecond * 10))
+	timer.ResetContext(context.Background(), time.Millisecond*10)
+	timer.Kill()
+	require.True(t, timer.Stopped())
+	require.EqualValues(t, 3, timer.Round())
+
+	_, err := results.WaitTimeout(time.Millisecond * 50)
+	require.EqualError(t, err, channel.ContextDoneErr.Error())
 }